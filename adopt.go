@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// adoptExisting looks for live instances in the compartment that this run
+// already launched, tagged with runID and carrying the display name of one of
+// configs, such as those left by an earlier attempt at the same run. Each one
+// found is returned as a launch result to wait on instead of launching a
+// duplicate; the configs with no instance are returned to be launched.
+// Instances of other runs are never adopted, however their names collide: a
+// manifest that lists them would let terminate -file destroy another fleet.
+func adoptExisting(ctx context.Context, client ComputeAPI, compartmentID, runID string, configs []InstanceConfig, retry RetryPolicy) ([]InstanceResult, []InstanceConfig, error) {
+	if len(configs) == 0 || runID == "" {
+		return nil, configs, nil
+	}
+	instances, err := listInstances(ctx, client, core.ListInstancesRequest{CompartmentId: common.String(compartmentID)}, retry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("looking for existing instances: %w", err)
+	}
+
+	byName := make(map[string][]core.Instance)
+	for _, inst := range instances {
+		switch inst.LifecycleState {
+		case core.InstanceLifecycleStateTerminating, core.InstanceLifecycleStateTerminated:
+			continue
+		}
+		if inst.FreeformTags[runIDTag] != runID {
+			continue
+		}
+		name := derefString(inst.DisplayName)
+		byName[name] = append(byName[name], inst)
+	}
+
+	var adopted []InstanceResult
+	var rest []InstanceConfig
+	for _, config := range configs {
+		found := byName[config.DisplayName]
+		if len(found) == 0 {
+			rest = append(rest, config)
+			continue
+		}
+		sort.SliceStable(found, func(i, j int) bool {
+			return timeCreated(found[i]).Before(timeCreated(found[j]))
+		})
+		inst := found[0]
+		if len(found) > 1 {
+			fmt.Printf("↻ %s already exists as %d live instances of this run, adopting the oldest: %s (%s)\n",
+				config.DisplayName, len(found), *inst.Id, inst.LifecycleState)
+		} else {
+			fmt.Printf("↻ %s already exists as %s (%s), adopting it\n", config.DisplayName, *inst.Id, inst.LifecycleState)
+		}
+		adopted = append(adopted, adoptedResult(config, inst))
+	}
+	return adopted, rest, nil
+}
+
+// adoptedResult turns an existing instance into the launch result for config
+func adoptedResult(config InstanceConfig, inst core.Instance) InstanceResult {
+	result := InstanceResult{
+		Index:        config.Index,
+		InstanceName: config.DisplayName,
+		InstanceID:   *inst.Id,
+		Shape:        derefString(inst.Shape),
+		Placement: Placement{
+			AvailabilityDomain: derefString(inst.AvailabilityDomain),
+			FaultDomain:        derefString(inst.FaultDomain),
+		},
+		State:   inst.LifecycleState,
+		Adopted: true,
+	}
+	if inst.TimeCreated != nil {
+		result.LaunchStarted = inst.TimeCreated.Time
+	}
+	return result
+}
+
+// timeCreated returns when inst was created, or the zero time if not known
+func timeCreated(inst core.Instance) time.Time {
+	if inst.TimeCreated == nil {
+		return time.Time{}
+	}
+	return inst.TimeCreated.Time
+}
+
+// listInstances returns every instance matching request, following pages
+func listInstances(ctx context.Context, client ComputeAPI, request core.ListInstancesRequest, retry RetryPolicy) ([]core.Instance, error) {
+	var instances []core.Instance
+	for {
+		var resp core.ListInstancesResponse
+		_, err := withRetry(ctx, retry, "list instances", func(ctx context.Context) error {
+			var err error
+			resp, err = client.ListInstances(ctx, request)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return instances, nil
+		}
+		request.Page = resp.OpcNextPage
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+func TestAdoptExistingOnlyAdoptsThisRun(t *testing.T) {
+	clock := ocifake.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := ocifake.New(ocifake.WithClock(clock.Now))
+	first := launchFake(t, b, "web-1", "run-a")
+	clock.Advance(time.Minute)
+	launchFake(t, b, "web-1", "run-a")
+	launchFake(t, b, "web-2", "run-b")
+	launchFake(t, b, "web-3", "")
+	gone := launchFake(t, b, "web-4", "run-a")
+	if _, err := b.TerminateInstance(context.Background(), core.TerminateInstanceRequest{InstanceId: common.String(gone)}); err != nil {
+		t.Fatal(err)
+	}
+
+	configs := []InstanceConfig{{Index: 1, DisplayName: "web-1"}, {Index: 2, DisplayName: "web-2"}, {Index: 3, DisplayName: "web-3"}, {Index: 4, DisplayName: "web-4"}}
+	adopted, rest, err := adoptExisting(context.Background(), b, "ocid1.compartment.test", "run-a", configs, testRetry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(adopted) != 1 || adopted[0].InstanceID != first || !adopted[0].Adopted {
+		t.Errorf("adopted %+v, want only the oldest web-1 of run-a, %s", adopted, first)
+	}
+	if !reflect.DeepEqual(rest, configs[1:]) {
+		t.Errorf("left to launch %+v, want web-2, web-3 and web-4", rest)
+	}
+
+	adopted, rest, err = adoptExisting(context.Background(), b, "ocid1.compartment.test", "", configs, testRetry)
+	if err != nil || len(adopted) != 0 || len(rest) != len(configs) {
+		t.Errorf("without a run ID adopted %+v, left %d, err %v; want nothing adopted", adopted, len(rest), err)
+	}
+}
+
+// withoutTimeCreated lists instances with no creation time, as some services
+// report them
+type withoutTimeCreated struct{ *ocifake.Backend }
+
+func (w withoutTimeCreated) ListInstances(ctx context.Context, request core.ListInstancesRequest) (core.ListInstancesResponse, error) {
+	resp, err := w.Backend.ListInstances(ctx, request)
+	for i := range resp.Items {
+		resp.Items[i].TimeCreated = nil
+	}
+	return resp, err
+}
+
+func TestAdoptExistingWithoutTimeCreated(t *testing.T) {
+	b := ocifake.New()
+	launchFake(t, b, "web-1", "run-a")
+	launchFake(t, b, "web-1", "run-a")
+
+	configs := []InstanceConfig{{Index: 1, DisplayName: "web-1"}}
+	adopted, rest, err := adoptExisting(context.Background(), withoutTimeCreated{b}, "ocid1.compartment.test", "run-a", configs, testRetry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(adopted) != 1 || len(rest) != 0 {
+		t.Errorf("adopted %d and left %d, want one of the duplicates adopted", len(adopted), len(rest))
+	}
+}
+
+func TestListInstancesFollowsPagesAndRetries(t *testing.T) {
+	b := ocifake.New()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		launchFake(t, b, name, "")
+	}
+	b.InjectError(ocifake.OpListInstances, ocifake.TooManyRequests(), 1)
+
+	request := core.ListInstancesRequest{CompartmentId: common.String("ocid1.compartment.test"), Limit: common.Int(2)}
+	instances, err := listInstances(context.Background(), b, request, testRetry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 5 {
+		t.Errorf("listed %d instances, want all 5", len(instances))
+	}
+	if calls := b.Calls(ocifake.OpListInstances); calls != 4 {
+		t.Errorf("made %d ListInstances calls, want 3 pages and a retry", calls)
+	}
+
+	b.InjectError(ocifake.OpListInstances, ocifake.NotFound("ocid1.compartment.test"), 1)
+	if _, err := listInstances(context.Background(), b, request, testRetry); err == nil || !errors.As(err, new(ocifake.ServiceError)) {
+		t.Errorf("listing after a 404 = %v, want the service error without retrying", err)
+	}
+}
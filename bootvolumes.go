@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// cleanupBootVolumes deletes the boot volumes in a compartment that are not
+// attached to any instance, such as those kept by terminate
+// -preserve-boot-volume
+func cleanupBootVolumes(ctx context.Context, fs *flag.FlagSet, shared *commonFlags, args []string) error {
+	var (
+		compartment = fs.String("compartment", "", "Compartment ID to clean up (required)")
+		ads         = fs.String("ad", "", "Comma-separated availability domains to clean up (default: all)")
+		olderThan   = fs.Duration("older-than", 0, "Only delete boot volumes created at least this long ago, e.g. 24h")
+		tags        = fs.String("tag", "", "Only delete boot volumes with these comma-separated freeform tags as key=value")
+		parallel    = fs.Int("parallel", 10, "Number of parallel delete operations")
+		dryRun      = fs.Bool("dry-run", false, "Show the boot volumes that would be deleted without deleting them")
+		assumeYes   = fs.Bool("yes", false, "Delete without asking for confirmation")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := shared.load()
+	if err != nil {
+		return err
+	}
+	fromConfig(flagsSet(fs), "compartment", compartment, config.CompartmentID)
+	if *compartment == "" {
+		return usageErrorf(fs, "compartment flag is required")
+	}
+	if *parallel < 1 {
+		return usageErrorf(fs, "parallel must be at least 1")
+	}
+	wantTags, err := mergeTags(nil, *tags)
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+
+	client, err := NewOCIClient(config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Looking for unattached boot volumes in %s...\n", *compartment)
+	volumes, err := unattachedBootVolumes(ctx, client, *compartment, splitList(*ads), config.Retry)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-*olderThan)
+	var selected []core.BootVolume
+	for _, bv := range volumes {
+		if *olderThan > 0 && (bv.TimeCreated == nil || bv.TimeCreated.After(cutoff)) {
+			continue
+		}
+		if !hasTags(bv.FreeformTags, wantTags) {
+			continue
+		}
+		selected = append(selected, bv)
+	}
+	if len(selected) == 0 {
+		fmt.Println("No unattached boot volumes match")
+		return nil
+	}
+
+	var totalGBs int64
+	for _, bv := range selected {
+		totalGBs += bootVolumeSizeGBs(bv)
+	}
+	fmt.Printf("%d unattached boot volume(s), %d GB in total:\n", len(selected), totalGBs)
+	printBootVolumePreview(selected)
+	if *dryRun {
+		fmt.Println("\nDry run: nothing was deleted")
+		return nil
+	}
+	if !*assumeYes && !confirm(fmt.Sprintf("\nDelete these %d boot volume(s)?", len(selected))) {
+		fmt.Println("Nothing was deleted")
+		return nil
+	}
+
+	failures := deleteBootVolumes(ctx, client.BlockstorageClient, selected, *parallel, config.Retry)
+	fmt.Printf("\nSummary: %d/%d boot volumes deleted\n", len(selected)-failures, len(selected))
+	if failures > 0 {
+		return fmt.Errorf("%d of %d boot volumes could not be deleted", failures, len(selected))
+	}
+	return nil
+}
+
+// unattachedBootVolumes returns the AVAILABLE boot volumes in compartmentID,
+// limited to ads if any are given, that no instance has attached, oldest first
+func unattachedBootVolumes(ctx context.Context, client *OCIClient, compartmentID string, ads []string, retry RetryPolicy) ([]core.BootVolume, error) {
+	available, attachedTo, err := bootVolumeInventory(ctx, client, compartmentID, ads, retry)
+	if err != nil {
+		return nil, err
+	}
+	var unattached []core.BootVolume
+	for _, bv := range available {
+		if attachedTo[*bv.Id] == "" {
+			unattached = append(unattached, bv)
+		}
+	}
+	sort.SliceStable(unattached, func(i, j int) bool {
+		return bootVolumeCreated(unattached[i]).Before(bootVolumeCreated(unattached[j]))
+	})
+	return unattached, nil
+}
+
+// bootVolumeInventory returns the AVAILABLE boot volumes in compartmentID,
+// limited to ads if any are given, and the instance each attached one belongs
+// to, keyed by boot volume ID
+func bootVolumeInventory(ctx context.Context, client *OCIClient, compartmentID string, ads []string, retry RetryPolicy) ([]core.BootVolume, map[string]string, error) {
+	if len(ads) == 0 {
+		ads = []string{""}
+	}
+	var available []core.BootVolume
+	for _, ad := range ads {
+		request := core.ListBootVolumesRequest{CompartmentId: common.String(compartmentID)}
+		if ad != "" {
+			request.AvailabilityDomain = common.String(ad)
+		}
+		for {
+			var resp core.ListBootVolumesResponse
+			_, err := withRetry(ctx, retry, "list boot volumes", func(ctx context.Context) error {
+				var err error
+				resp, err = client.BlockstorageClient.ListBootVolumes(ctx, request)
+				return err
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("listing boot volumes: %w", err)
+			}
+			for _, bv := range resp.Items {
+				if bv.LifecycleState == core.BootVolumeLifecycleStateAvailable {
+					available = append(available, bv)
+				}
+			}
+			if resp.OpcNextPage == nil {
+				break
+			}
+			request.Page = resp.OpcNextPage
+		}
+	}
+
+	// Attachments can only be listed one availability domain at a time
+	attachedTo := make(map[string]string)
+	seenAD := make(map[string]bool)
+	for _, bv := range available {
+		ad := derefString(bv.AvailabilityDomain)
+		if seenAD[ad] {
+			continue
+		}
+		seenAD[ad] = true
+		request := core.ListBootVolumeAttachmentsRequest{
+			AvailabilityDomain: common.String(ad),
+			CompartmentId:      common.String(compartmentID),
+		}
+		for {
+			var resp core.ListBootVolumeAttachmentsResponse
+			_, err := withRetry(ctx, retry, "list boot volume attachments", func(ctx context.Context) error {
+				var err error
+				resp, err = client.ComputeClient.ListBootVolumeAttachments(ctx, request)
+				return err
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("listing boot volume attachments in %s: %w", ad, err)
+			}
+			for _, a := range resp.Items {
+				if a.LifecycleState != core.BootVolumeAttachmentLifecycleStateDetached {
+					attachedTo[derefString(a.BootVolumeId)] = derefString(a.InstanceId)
+				}
+			}
+			if resp.OpcNextPage == nil {
+				break
+			}
+			request.Page = resp.OpcNextPage
+		}
+	}
+	return available, attachedTo, nil
+}
+
+// hasTags reports whether tags contains every key and value in want
+func hasTags(tags, want map[string]string) bool {
+	for k, v := range want {
+		if got, ok := tags[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// bootVolumeCreated returns when bv was created, or the zero time if not known
+func bootVolumeCreated(bv core.BootVolume) time.Time {
+	if bv.TimeCreated == nil {
+		return time.Time{}
+	}
+	return bv.TimeCreated.Time
+}
+
+// bootVolumeSizeGBs returns the size of bv in GB
+func bootVolumeSizeGBs(bv core.BootVolume) int64 {
+	switch {
+	case bv.SizeInGBs != nil:
+		return *bv.SizeInGBs
+	case bv.SizeInMBs != nil:
+		return *bv.SizeInMBs / 1024
+	}
+	return 0
+}
+
+// printBootVolumePreview lists boot volumes one per line with the details
+// needed to check them before deleting
+func printBootVolumePreview(volumes []core.BootVolume) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tSIZE\tAVAILABILITY DOMAIN\tCREATED\tID")
+	for _, bv := range volumes {
+		created := ""
+		if t := bootVolumeCreated(bv); !t.IsZero() {
+			created = t.UTC().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "  %s\t%d GB\t%s\t%s\t%s\n", derefString(bv.DisplayName), bootVolumeSizeGBs(bv),
+			derefString(bv.AvailabilityDomain), created, *bv.Id)
+	}
+	w.Flush()
+}
+
+// deleteBootVolumes deletes volumes, at most parallel at once, reporting each
+// result as it completes, and returns how many could not be deleted
+func deleteBootVolumes(ctx context.Context, client BlockstorageAPI, volumes []core.BootVolume, parallel int, retry RetryPolicy) int {
+	type result struct {
+		id  string
+		err error
+	}
+	results := make(chan result, len(volumes))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, parallel)
+
+	for _, bv := range volumes {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			semaphore <- struct{}{}        // Acquire
+			defer func() { <-semaphore }() // Release
+
+			_, err := withRetry(ctx, retry, "delete "+id, func(ctx context.Context) error {
+				_, err := client.DeleteBootVolume(ctx, core.DeleteBootVolumeRequest{BootVolumeId: common.String(id)})
+				return err
+			})
+			results <- result{id: id, err: err}
+		}(*bv.Id)
+	}
+
+	// Wait for all goroutines to complete
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	failures := 0
+	for r := range results {
+		if r.err != nil {
+			fmt.Printf("❌ Failed to delete %s: %v\n", r.id, describeError(r.err))
+			failures++
+			continue
+		}
+		fmt.Printf("✓ Deleted boot volume %s\n", r.id)
+	}
+	return failures
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// cleanupKind is a kind of resource the cleanup command can delete
+type cleanupKind struct {
+	Name    string
+	Args    string
+	Summary string
+	Run     func(ctx context.Context, fs *flag.FlagSet, shared *commonFlags, args []string) error
+}
+
+var cleanupKinds = []cleanupKind{
+	{"instance-configs", " <instance-configuration-id>...", "Delete instance configurations, such as the unused ones pool create reports after a shape fallback", cleanupInstanceConfigurations},
+	{"boot-volumes", "", "Delete boot volumes no instance is attached to, such as those kept by terminate -preserve-boot-volume", cleanupBootVolumes},
+}
+
+func printCleanupUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: oci-insta-scale cleanup <kind> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Kinds:")
+	for _, k := range cleanupKinds {
+		fmt.Fprintf(w, "  %-18s %s\n", k.Name, k.Summary)
+	}
+}
+
+// runCleanup implements the cleanup command
+func runCleanup(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		printCleanupUsage(os.Stderr)
+		return errUsage
+	}
+	for _, kind := range cleanupKinds {
+		if kind.Name == args[0] {
+			fs := newFlagSet("cleanup "+kind.Name, kind.Args, kind.Summary+".")
+			shared := registerCommonFlags(fs, "")
+			return kind.Run(ctx, fs, shared, args[1:])
+		}
+	}
+	switch args[0] {
+	case "-h", "-help", "--help":
+		printCleanupUsage(os.Stdout)
+		return flag.ErrHelp
+	}
+	fmt.Fprintf(os.Stderr, "Unknown cleanup kind: %s\n\n", args[0])
+	printCleanupUsage(os.Stderr)
+	return errUsage
+}
+
+// cleanupInstanceConfigurations deletes the instance configurations named on
+// the command line
+func cleanupInstanceConfigurations(ctx context.Context, fs *flag.FlagSet, shared *commonFlags, args []string) error {
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	ids := fs.Args()
+	if len(ids) == 0 {
+		return usageErrorf(fs, "at least one instance configuration ID is required")
+	}
+	config, err := shared.load()
+	if err != nil {
+		return err
+	}
+	client, err := NewOCIClient(config)
+	if err != nil {
+		return err
+	}
+
+	failureCount := 0
+	for _, id := range ids {
+		if err := client.DeleteInstanceConfiguration(ctx, id); err != nil {
+			fmt.Printf("❌ Failed to delete %s: %v\n", id, err)
+			failureCount++
+			continue
+		}
+		fmt.Printf("✓ Deleted instance configuration %s\n", id)
+	}
+
+	fmt.Printf("\nSummary: %d/%d instance configurations deleted\n", len(ids)-failureCount, len(ids))
+	if failureCount > 0 {
+		return fmt.Errorf("%d of %d instance configurations could not be deleted", failureCount, len(ids))
+	}
+	return nil
+}
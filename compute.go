@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+// ComputeAPI is the subset of the OCI Compute API used to launch, find, poll
+// and terminate instances and to see which boot and block volumes are attached.
+// core.ComputeClient satisfies it; tests can substitute an in-memory
+// implementation such as ocifake.Backend.
+type ComputeAPI interface {
+	LaunchInstance(ctx context.Context, request core.LaunchInstanceRequest) (core.LaunchInstanceResponse, error)
+	GetInstance(ctx context.Context, request core.GetInstanceRequest) (core.GetInstanceResponse, error)
+	ListInstances(ctx context.Context, request core.ListInstancesRequest) (core.ListInstancesResponse, error)
+	TerminateInstance(ctx context.Context, request core.TerminateInstanceRequest) (core.TerminateInstanceResponse, error)
+	ListBootVolumeAttachments(ctx context.Context, request core.ListBootVolumeAttachmentsRequest) (core.ListBootVolumeAttachmentsResponse, error)
+	ListVolumeAttachments(ctx context.Context, request core.ListVolumeAttachmentsRequest) (core.ListVolumeAttachmentsResponse, error)
+}
+
+// ComputeManagementAPI is the subset of the OCI ComputeManagement API used to
+// manage instance configurations and pools. core.ComputeManagementClient
+// satisfies it.
+type ComputeManagementAPI interface {
+	CreateInstanceConfiguration(ctx context.Context, request core.CreateInstanceConfigurationRequest) (core.CreateInstanceConfigurationResponse, error)
+	ListInstanceConfigurations(ctx context.Context, request core.ListInstanceConfigurationsRequest) (core.ListInstanceConfigurationsResponse, error)
+	DeleteInstanceConfiguration(ctx context.Context, request core.DeleteInstanceConfigurationRequest) (core.DeleteInstanceConfigurationResponse, error)
+	CreateInstancePool(ctx context.Context, request core.CreateInstancePoolRequest) (core.CreateInstancePoolResponse, error)
+	UpdateInstancePool(ctx context.Context, request core.UpdateInstancePoolRequest) (core.UpdateInstancePoolResponse, error)
+	GetInstancePool(ctx context.Context, request core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error)
+	ListInstancePools(ctx context.Context, request core.ListInstancePoolsRequest) (core.ListInstancePoolsResponse, error)
+	TerminateInstancePool(ctx context.Context, request core.TerminateInstancePoolRequest) (core.TerminateInstancePoolResponse, error)
+	ListInstancePoolInstances(ctx context.Context, request core.ListInstancePoolInstancesRequest) (core.ListInstancePoolInstancesResponse, error)
+	DetachInstancePoolInstance(ctx context.Context, request core.DetachInstancePoolInstanceRequest) (core.DetachInstancePoolInstanceResponse, error)
+}
+
+// BlockstorageAPI is the subset of the OCI Block Storage API used to clean up
+// boot and block volumes. core.BlockstorageClient satisfies it.
+type BlockstorageAPI interface {
+	ListBootVolumes(ctx context.Context, request core.ListBootVolumesRequest) (core.ListBootVolumesResponse, error)
+	DeleteBootVolume(ctx context.Context, request core.DeleteBootVolumeRequest) (core.DeleteBootVolumeResponse, error)
+	ListVolumes(ctx context.Context, request core.ListVolumesRequest) (core.ListVolumesResponse, error)
+	DeleteVolume(ctx context.Context, request core.DeleteVolumeRequest) (core.DeleteVolumeResponse, error)
+}
+
+// IdentityAPI is the subset of the OCI Identity API used to walk a compartment
+// tree. identity.IdentityClient satisfies it.
+type IdentityAPI interface {
+	ListCompartments(ctx context.Context, request identity.ListCompartmentsRequest) (identity.ListCompartmentsResponse, error)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+// The fake stands in for every OCI client the tool uses
+var (
+	_ ComputeAPI           = (*ocifake.Backend)(nil)
+	_ ComputeManagementAPI = (*ocifake.Backend)(nil)
+	_ BlockstorageAPI      = (*ocifake.Backend)(nil)
+	_ IdentityAPI          = (*ocifake.Backend)(nil)
+)
+
+// testRetry retries without waiting, so injected faults do not slow tests down
+var testRetry = RetryPolicy{MaxAttempts: 3}
+
+// launchFake starts an instance in the fake named name and tagged with runID
+// unless it is empty
+func launchFake(t *testing.T, b *ocifake.Backend, name, runID string) string {
+	t.Helper()
+	details := core.LaunchInstanceDetails{
+		CompartmentId:      common.String("ocid1.compartment.test"),
+		AvailabilityDomain: common.String("AD-1"),
+		Shape:              common.String("VM.Standard2.1"),
+		DisplayName:        common.String(name),
+	}
+	if runID != "" {
+		details.FreeformTags = map[string]string{runIDTag: runID}
+	}
+	resp, err := b.LaunchInstance(context.Background(), core.LaunchInstanceRequest{LaunchInstanceDetails: details})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *resp.Id
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the fleet description read by every command. The pool commands
+// use all of it; launch treats instance_pool.size as the instance count and
+// the placement list as its availability and fault domains, and ignores
+// load_balancers. JSON files with the same keys are accepted too.
+type Config struct {
+	// OCI Authentication (optional; ~/.oci/config is used when tenancy_ocid is empty)
+	TenancyOCID    string `yaml:"tenancy_ocid"`
+	UserOCID       string `yaml:"user_ocid"`
+	Fingerprint    string `yaml:"fingerprint"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+	Region         string `yaml:"region"`
+	Endpoint       string `yaml:"endpoint,omitempty"` // optional API endpoint override, e.g. an ocifake server
+
+	// Instance Pool Configuration
+	CompartmentID string             `yaml:"compartment_id"`
+	InstancePool  InstancePoolConfig `yaml:"instance_pool"`
+
+	// How long launched instances and created pools live before reap may
+	// remove them; 0 means they never expire
+	TTL time.Duration `yaml:"ttl,omitempty"`
+
+	// Retry policy for transient API errors
+	Retry RetryPolicy `yaml:"retry,omitempty"`
+
+	// Client-side API rate limits
+	RateLimit RateLimits `yaml:"rate_limit,omitempty"`
+
+	// What the sweep command removes
+	Sweep SweepPolicy `yaml:"sweep,omitempty"`
+
+	// What the reap command protects and where it records what it removed
+	Reap ReapPolicy `yaml:"reap,omitempty"`
+}
+
+// InstancePoolConfig defines the instance pool settings
+type InstancePoolConfig struct {
+	DisplayName           string                    `yaml:"display_name"`
+	Size                  int                       `yaml:"size"`
+	MinSize               int                       `yaml:"min_size,omitempty"` // smallest size scale may set; 0 for no limit
+	MaxSize               int                       `yaml:"max_size,omitempty"` // largest size create and scale may set; 0 for no limit
+	MaxStep               int                       `yaml:"max_step,omitempty"` // largest change scale makes at once; 0 for no limit
+	ScaleIn               ScaleInPolicy             `yaml:"scale_in,omitempty"` // which instances scale removes when shrinking the pool
+	InstanceConfiguration InstanceConfigurationSpec `yaml:"instance_configuration"`
+	Placement             []PlacementConfig         `yaml:"placement"`
+	LoadBalancers         []LoadBalancerConfig      `yaml:"load_balancers,omitempty"`
+}
+
+// InstanceConfigurationSpec defines the VM configuration
+type InstanceConfigurationSpec struct {
+	DisplayName       string                            `yaml:"display_name"`
+	Shape             string                            `yaml:"shape"`
+	ShapeConfig       ShapeConfig                       `yaml:"shape_config,omitempty"`
+	ImageID           string                            `yaml:"image_id"`
+	SubnetID          string                            `yaml:"subnet_id"`
+	AssignPublicIP    bool                              `yaml:"assign_public_ip"`
+	SSHAuthorizedKeys string                            `yaml:"ssh_authorized_keys,omitempty"`
+	UserData          string                            `yaml:"user_data,omitempty"`
+	Metadata          map[string]string                 `yaml:"metadata,omitempty"`
+	FreeformTags      map[string]string                 `yaml:"freeform_tags,omitempty"`
+	DefinedTags       map[string]map[string]interface{} `yaml:"defined_tags,omitempty"`
+
+	// Shapes to fall back to, in order, on capacity or service limit errors
+	FallbackShapes []ShapeChoice `yaml:"fallback_shapes,omitempty"`
+}
+
+// ShapeChoice is a shape and its flexible shape configuration
+type ShapeChoice struct {
+	Shape       string      `yaml:"shape"`
+	ShapeConfig ShapeConfig `yaml:"shape_config,omitempty"`
+}
+
+// Shapes returns the primary shape followed by the fallback shapes
+func (s InstanceConfigurationSpec) Shapes() []ShapeChoice {
+	return append([]ShapeChoice{{Shape: s.Shape, ShapeConfig: s.ShapeConfig}}, s.FallbackShapes...)
+}
+
+// ShapeConfig defines flexible shape configuration (for flex shapes)
+type ShapeConfig struct {
+	Ocpus                   float32 `yaml:"ocpus,omitempty"`
+	MemoryInGBs             float32 `yaml:"memory_in_gbs,omitempty"`
+	BaselineOcpuUtilization string  `yaml:"baseline_ocpu_utilization,omitempty"` // 1/8, 1/2 or 1/1 for burstable instances
+	Nvmes                   int     `yaml:"nvmes,omitempty"`
+}
+
+// PlacementConfig defines availability domain and fault domain placement
+type PlacementConfig struct {
+	AvailabilityDomain string   `yaml:"availability_domain"`
+	FaultDomains       []string `yaml:"fault_domains,omitempty"`
+	Weight             int      `yaml:"weight,omitempty"` // launcher spread strategy only; pools always spread evenly
+}
+
+// LoadBalancerConfig defines load balancer attachment
+type LoadBalancerConfig struct {
+	LoadBalancerID string `yaml:"load_balancer_id"`
+	BackendSetName string `yaml:"backend_set_name"`
+	Port           int    `yaml:"port"`
+	VnicSelection  string `yaml:"vnic_selection"`
+}
+
+// LoadConfig loads configuration from a YAML or JSON file. Only the API key
+// settings are checked here; flags can supply anything else the file leaves
+// out, and commands that need more call Validate.
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Start from the defaults so partial retry, rate_limit and sweep sections
+	// only override what they set. JSON is a subset of YAML, so one parser
+	// reads both.
+	config := Config{Retry: DefaultRetryPolicy(), RateLimit: DefaultRateLimits(), Sweep: DefaultSweepPolicy(), Reap: DefaultReapPolicy()}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	// API key settings are all or nothing
+	if config.TenancyOCID != "" {
+		if config.UserOCID == "" {
+			return nil, fmt.Errorf("user_ocid is required with tenancy_ocid")
+		}
+		if config.Fingerprint == "" {
+			return nil, fmt.Errorf("fingerprint is required with tenancy_ocid")
+		}
+		if config.PrivateKeyPath == "" {
+			return nil, fmt.Errorf("private_key_path is required with tenancy_ocid")
+		}
+		if config.Region == "" {
+			return nil, fmt.Errorf("region is required with tenancy_ocid")
+		}
+	}
+
+	return &config, nil
+}
+
+// Validate checks the fields needed to create an instance pool
+func (c *Config) Validate() error {
+	if c.CompartmentID == "" {
+		return fmt.Errorf("compartment_id is required")
+	}
+	// Note: Size can be 0 in config if overridden by command-line flag
+	if c.InstancePool.InstanceConfiguration.Shape == "" {
+		return fmt.Errorf("instance_pool.instance_configuration.shape is required")
+	}
+	for i, fallback := range c.InstancePool.InstanceConfiguration.FallbackShapes {
+		if fallback.Shape == "" {
+			return fmt.Errorf("instance_pool.instance_configuration.fallback_shapes[%d].shape is required", i)
+		}
+	}
+	for _, choice := range c.InstancePool.InstanceConfiguration.Shapes() {
+		if _, err := parseBaseline(choice.ShapeConfig.BaselineOcpuUtilization); err != nil {
+			return fmt.Errorf("%s: %w", choice.Shape, err)
+		}
+	}
+	if c.InstancePool.InstanceConfiguration.ImageID == "" {
+		return fmt.Errorf("instance_pool.instance_configuration.image_id is required")
+	}
+	if c.InstancePool.InstanceConfiguration.SubnetID == "" {
+		return fmt.Errorf("instance_pool.instance_configuration.subnet_id is required")
+	}
+	if len(c.InstancePool.Placement) == 0 {
+		return fmt.Errorf("at least one placement configuration is required")
+	}
+	if err := c.InstancePool.validateBounds(); err != nil {
+		return err
+	}
+	if err := c.InstancePool.ScaleIn.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateBounds checks min_size, max_size and max_step make sense together
+func (p InstancePoolConfig) validateBounds() error {
+	if p.MinSize < 0 || p.MaxSize < 0 || p.MaxStep < 0 {
+		return fmt.Errorf("instance_pool.min_size, max_size and max_step must not be negative")
+	}
+	if p.MaxSize > 0 && p.MinSize > p.MaxSize {
+		return fmt.Errorf("instance_pool.min_size %d is greater than max_size %d", p.MinSize, p.MaxSize)
+	}
+	return nil
+}
+
+// checkSize reports whether size is outside min_size and max_size
+func (p InstancePoolConfig) checkSize(size int) error {
+	if size < p.MinSize {
+		return fmt.Errorf("size %d is below instance_pool.min_size %d", size, p.MinSize)
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		return fmt.Errorf("size %d is above instance_pool.max_size %d", size, p.MaxSize)
+	}
+	return nil
+}
+
+// clampSize returns size held within min_size and max_size
+func (p InstancePoolConfig) clampSize(size int) int {
+	if size < p.MinSize {
+		size = p.MinSize
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		size = p.MaxSize
+	}
+	return size
+}
+
+// configurationProvider returns API key credentials from the config file when
+// it has them, and the standard ~/.oci/config provider otherwise
+func (c *Config) configurationProvider() (common.ConfigurationProvider, error) {
+	if c == nil || c.TenancyOCID == "" {
+		return common.DefaultConfigProvider(), nil
+	}
+	privateKey, err := os.ReadFile(c.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key file: %w", err)
+	}
+	return common.NewRawConfigurationProvider(c.TenancyOCID, c.UserOCID, c.Region, c.Fingerprint, string(privateKey), nil), nil
+}
+
+// availabilityDomains converts the placement list for the placement strategies
+func (c *Config) availabilityDomains() []weightedAD {
+	var ads []weightedAD
+	for _, p := range c.InstancePool.Placement {
+		weight := p.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		ads = append(ads, weightedAD{Name: p.AvailabilityDomain, Weight: weight, FaultDomains: p.FaultDomains})
+	}
+	return ads
+}
+
+// shapeOptions converts the primary and fallback shapes, with overrides from
+// any shape flags the user gave, and validates them
+func (c *Config) shapeOptions(overrides ShapeOption, set map[string]bool) ([]ShapeOption, error) {
+	choices := c.InstancePool.InstanceConfiguration.Shapes()
+	var shapes []ShapeOption
+	for _, choice := range choices {
+		baseline, err := parseBaseline(choice.ShapeConfig.BaselineOcpuUtilization)
+		if err != nil {
+			return nil, fmt.Errorf("shape %s: %w", choice.Shape, err)
+		}
+		option := ShapeOption{
+			Shape:       choice.Shape,
+			Ocpus:       choice.ShapeConfig.Ocpus,
+			MemoryInGBs: choice.ShapeConfig.MemoryInGBs,
+			Baseline:    baseline,
+			Nvmes:       choice.ShapeConfig.Nvmes,
+		}
+		// Shape flags override every flexible shape in the chain. As with
+		// -shape, they only count as asking a fixed shape for a config when
+		// there is a single shape.
+		explicit := choice.ShapeConfig != ShapeConfig{}
+		flagged := set["ocpus"] || set["memory"] || set["baseline"] || set["nvmes"]
+		if flagged && isFlexShape(choice.Shape) {
+			if set["ocpus"] {
+				option.Ocpus = overrides.Ocpus
+				option.MemoryInGBs = 0
+			}
+			if set["memory"] {
+				option.MemoryInGBs = overrides.MemoryInGBs
+			}
+			if set["baseline"] {
+				option.Baseline = overrides.Baseline
+			}
+			if set["nvmes"] {
+				option.Nvmes = overrides.Nvmes
+			}
+		}
+		if flagged && len(choices) == 1 {
+			explicit = true
+		}
+
+		resolved, err := option.resolve(explicit)
+		if err != nil {
+			return nil, err
+		}
+		shapes = append(shapes, resolved)
+	}
+	return shapes, nil
+}
+
+// fromConfig copies value into dst unless the named flag was given or value is empty
+func fromConfig(set map[string]bool, name string, dst *string, value string) {
+	if !set[name] && value != "" {
+		*dst = value
+	}
+}
+
+// launchMetadata builds instance metadata the way the pool tool's instance
+// configuration does, with -ssh-key and -user-data files taking precedence
+// over ssh_authorized_keys and user_data from the config
+func launchMetadata(spec InstanceConfigurationSpec, sshKeyFile, userDataFile string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for k, v := range spec.Metadata {
+		metadata[k] = v
+	}
+	if spec.SSHAuthorizedKeys != "" {
+		metadata["ssh_authorized_keys"] = spec.SSHAuthorizedKeys
+	}
+	if spec.UserData != "" {
+		metadata["user_data"] = spec.UserData
+	}
+
+	if sshKeyFile != "" {
+		key, err := os.ReadFile(sshKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		metadata["ssh_authorized_keys"] = strings.TrimSpace(string(key))
+	}
+	if userDataFile != "" {
+		data, err := os.ReadFile(userDataFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read user data: %w", err)
+		}
+		metadata["user_data"] = base64.StdEncoding.EncodeToString(data)
+	}
+
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
+
+// mergeTags adds comma-separated key=value tags from a flag to those from the config
+func mergeTags(base map[string]string, flagValue string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for k, v := range base {
+		tags[k] = v
+	}
+	for _, entry := range splitList(flagValue) {
+		k, v, ok := strings.Cut(entry, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid tag %q: use key=value", entry)
+		}
+		tags[k] = v
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
+}
@@ -4,6 +4,7 @@ go 1.21
 
 require (
 	github.com/oracle/oci-go-sdk/v65 v65.55.0
+	github.com/tomarkel/oci-insta-scale/ocifake v0.0.0-00010101000000-000000000000
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -12,3 +13,5 @@ require (
 	github.com/sony/gobreaker v0.5.0 // indirect
 	golang.org/x/sys v0.8.0 // indirect
 )
+
+replace github.com/tomarkel/oci-insta-scale/ocifake => ./ocifake
@@ -0,0 +1,591 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// runLaunch implements the launch command
+func runLaunch(ctx context.Context, args []string) error {
+	fs := newFlagSet("launch", "", "Launch standalone instances, falling back across shapes, ADs and fault domains, and\nrecord them in a run manifest for terminate.")
+	var (
+		numInstances       = fs.Int("instances", 1, "Number of instances to create")
+		displayName        = fs.String("name", "oci-instance", "Base name for instances")
+		imageID            = fs.String("image", "", "Image ID (required)")
+		shape              = fs.String("shape", "VM.Standard.E4.Flex", "Instance shape, or a comma-separated priority list; each entry may set shape:ocpus or shape:ocpus:memoryGB")
+		ocpus              = fs.Float64("ocpus", 1, "OCPUs for flexible shapes")
+		memory             = fs.Float64("memory", 0, "Memory in GB for flexible shapes (default: 8 GB per OCPU within the shape's limits)")
+		baseline           = fs.String("baseline", "", "Baseline OCPU utilization for burstable flexible shapes: 1/8, 1/2 or 1/1 (default: not burstable)")
+		nvmes              = fs.Int("nvmes", 0, "Local NVMe drives for DenseIO flexible shapes (default: sized by the service)")
+		subnetID           = fs.String("subnet", "", "Subnet ID (required)")
+		compartmentID      = fs.String("compartment", "", "Compartment ID (required)")
+		availabilityDomain = fs.String("ad", "", "Availability Domain, or a comma-separated list; with -placement spread each may carry a weight as AD=weight (required)")
+		faultDomains       = fs.String("fd", "", "Comma-separated fault domains to use within each AD (default: OCI chooses, or all three with -placement spread)")
+		strategy           = fs.String("placement", "ordered", "Placement strategy: ordered (first AD/FD, later ones only on capacity errors) or spread (round-robin, weighted by AD)")
+		outputFile         = fs.String("output", "instances.json", "Run manifest to write, recording every instance's OCID, placement, timings and errors")
+		parallel           = fs.Int("parallel", 0, "Maximum number of instances being created at once (0 for no limit)")
+		waveSize           = fs.Int("wave-size", 0, "Launch in waves of this many instances, waiting for each wave to finish (0 launches all at once)")
+		publicIP           = fs.Bool("public-ip", true, "Assign a public IP to each instance")
+		sshKeyFile         = fs.String("ssh-key", "", "Public key file to install as ssh_authorized_keys")
+		userDataFile       = fs.String("user-data", "", "cloud-init user data file (base64-encoded for you)")
+		tags               = fs.String("tags", "", "Comma-separated freeform tags as key=value, added to any from the config")
+		runID              = fs.String("run-id", "", "Run ID to launch as (default: a new one); reusing an earlier run's ID reuses its retry tokens")
+		ttl                = fs.Duration("ttl", 0, "Tag instances to expire this long after the run starts, e.g. 4h (overrides config; 0 for never)")
+		adopt              = fs.Bool("adopt", true, "With -run-id, adopt live instances of that run that already have an instance's display name instead of launching them")
+		terminateOnAbort   = fs.Bool("terminate-on-interrupt", false, "On Ctrl-C, terminate every instance the run launched instead of leaving them for resume")
+	)
+	shared := registerCommonFlags(fs, "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// Anything not given as a flag comes from the config file, if there is one
+	fileConfig, err := shared.load()
+	if err != nil {
+		return err
+	}
+	set := flagsSet(fs)
+	spec := fileConfig.InstancePool.InstanceConfiguration
+	if !set["instances"] && fileConfig.InstancePool.Size > 0 {
+		*numInstances = fileConfig.InstancePool.Size
+	}
+	if !set["name"] && spec.DisplayName != "" {
+		*displayName = spec.DisplayName
+	} else if !set["name"] && fileConfig.InstancePool.DisplayName != "" {
+		*displayName = fileConfig.InstancePool.DisplayName
+	}
+	fromConfig(set, "image", imageID, spec.ImageID)
+	fromConfig(set, "subnet", subnetID, spec.SubnetID)
+	fromConfig(set, "compartment", compartmentID, fileConfig.CompartmentID)
+	if !set["public-ip"] && *shared.configFile != "" {
+		*publicIP = spec.AssignPublicIP
+	}
+	if !set["ttl"] {
+		*ttl = fileConfig.TTL
+	}
+
+	if *imageID == "" || *subnetID == "" || *compartmentID == "" || (*availabilityDomain == "" && len(fileConfig.InstancePool.Placement) == 0) {
+		return usageErrorf(fs, "image, subnet, compartment, and ad flags are required (or set them in -config)")
+	}
+
+	// Create OCI client
+	client, err := NewOCIClient(fileConfig)
+	if err != nil {
+		return err
+	}
+	api := client.ComputeClient
+	retry := fileConfig.Retry
+
+	baselineUtilization, err := parseBaseline(*baseline)
+	if err != nil {
+		return err
+	}
+	shapeFlags := ShapeOption{
+		Ocpus:       float32(*ocpus),
+		MemoryInGBs: float32(*memory),
+		Baseline:    baselineUtilization,
+		Nvmes:       *nvmes,
+	}
+	var shapes []ShapeOption
+	if set["shape"] || fileConfig.InstancePool.InstanceConfiguration.Shape == "" {
+		shapes, err = parseShapes(*shape, shapeFlags, set["ocpus"] || set["memory"] || set["baseline"] || set["nvmes"])
+	} else {
+		shapes, err = fileConfig.shapeOptions(shapeFlags, set)
+	}
+	if err != nil {
+		return err
+	}
+	if len(shapes) > 1 {
+		fmt.Printf("Shapes tried in order on capacity or limit errors: %s\n", formatShapes(shapes))
+	} else {
+		fmt.Printf("Shape: %s\n", shapes[0])
+	}
+
+	var ads []weightedAD
+	if set["ad"] || len(fileConfig.InstancePool.Placement) == 0 {
+		ads, err = parseAvailabilityDomains(*availabilityDomain)
+		if err != nil {
+			return err
+		}
+	} else {
+		ads = fileConfig.availabilityDomains()
+	}
+	if fds := splitList(*faultDomains); len(fds) > 0 {
+		for i := range ads {
+			ads[i].FaultDomains = fds
+		}
+	}
+
+	metadata, err := launchMetadata(fileConfig.InstancePool.InstanceConfiguration, *sshKeyFile, *userDataFile)
+	if err != nil {
+		return err
+	}
+	freeformTags, err := mergeTags(fileConfig.InstancePool.InstanceConfiguration.FreeformTags, *tags)
+	if err != nil {
+		return err
+	}
+
+	// Every instance gets an ordered list of placements to try. With the
+	// spread strategy each instance's planned placement comes first, followed
+	// by the others as capacity fallbacks.
+	var placements []Placement
+	var plan []Placement
+	switch *strategy {
+	case "ordered":
+		placements = orderedPlacements(ads, nil)
+		if len(placements) > 1 {
+			fmt.Printf("Placements tried in order on capacity errors: %s\n", formatPlacements(placements))
+		}
+	case "spread":
+		placements = orderedPlacements(ads, defaultFaultDomains)
+		plan = planSpread(ads, *numInstances)
+		fmt.Printf("Spread plan: %s\n", formatPlan(plan))
+	default:
+		return usageErrorf(fs, "unknown placement strategy %q (use ordered or spread)", *strategy)
+	}
+
+	// The manifest records the request up front and is journaled as each
+	// instance launches, so an interrupted run can be picked up with resume
+	started := time.Now().UTC()
+	if *runID == "" {
+		*runID = newRunID(started)
+	}
+	manifest := &Manifest{
+		Version:   manifestVersion,
+		RunID:     *runID,
+		StartedAt: started,
+		CreatedBy: client.UserOCID,
+		ExpiresAt: expiryAfter(started, *ttl),
+		Request: ManifestRequest{
+			Instances:      *numInstances,
+			Name:           *displayName,
+			CompartmentID:  *compartmentID,
+			ImageID:        *imageID,
+			SubnetID:       *subnetID,
+			Shapes:         shapes,
+			Strategy:       *strategy,
+			Placements:     placements,
+			Plan:           plan,
+			AssignPublicIP: *publicIP,
+			Metadata:       metadata,
+			FreeformTags:   freeformTags,
+			DefinedTags:    fileConfig.InstancePool.InstanceConfiguration.DefinedTags,
+			Parallel:       *parallel,
+			WaveSize:       *waveSize,
+			ConfigFile:     *shared.configFile,
+		},
+	}
+	journal, err := newRunJournal(*outputFile, manifest)
+	if err != nil {
+		return fmt.Errorf("writing run manifest: %w", err)
+	}
+	fmt.Printf("Run ID: %s (manifest: %s)\n", manifest.RunID, *outputFile)
+
+	configs := make([]InstanceConfig, *numInstances)
+	for i := range configs {
+		configs[i] = manifest.instanceConfig(i + 1)
+	}
+
+	if *parallel > 0 {
+		fmt.Printf("Creating %d instance(s), at most %d at a time...\n", *numInstances, *parallel)
+	} else {
+		fmt.Printf("Creating %d instance(s) in parallel...\n", *numInstances)
+	}
+	tally := newLaunchTally()
+	// Only instances tagged with this run are adopted, so a new run has none
+	if *adopt && set["run-id"] {
+		adopted, rest, err := adoptExisting(ctx, api, *compartmentID, manifest.RunID, configs, retry)
+		if err != nil {
+			return err
+		}
+		awaitLaunched(ctx, api, adopted, *parallel, retry, journal, tally)
+		configs = rest
+	}
+	launchWaves(ctx, api, planWaves(configs, *waveSize), *parallel, retry, journal, tally)
+
+	fmt.Printf("\nSummary: %d/%d instances created successfully\n", tally.succeeded, *numInstances)
+	tally.print()
+
+	if err := journal.finish(); err != nil {
+		return fmt.Errorf("writing run manifest: %w", err)
+	}
+	fmt.Printf("Run manifest written to %s\n", *outputFile)
+	if ctx.Err() != nil {
+		return interruptedRun(ctx, api, journal, *terminateOnAbort, retry)
+	}
+	if tally.succeeded < *numInstances {
+		return fmt.Errorf("%d of %d instances failed to launch", *numInstances-tally.succeeded, *numInstances)
+	}
+	return nil
+}
+
+// interruptedRun finishes a launch or resume stopped by Ctrl-C. The manifest
+// already records every instance launched so far. With terminate, everything
+// the run launched is terminated as well, including instances whose launch was
+// accepted but never answered, which are found by their run ID tag.
+func interruptedRun(ctx context.Context, api ComputeAPI, journal *runJournal, terminate bool, retry RetryPolicy) error {
+	manifest := journal.manifest
+	interrupted := fmt.Errorf("run %s interrupted: %w", manifest.RunID, ctx.Err())
+	if !terminate {
+		fmt.Printf("Run %s interrupted. Finish it with 'oci-insta-scale resume -file %s', or remove its instances with 'oci-insta-scale terminate -file %s'\n",
+			manifest.RunID, journal.path, journal.path)
+		return interrupted
+	}
+
+	// The command's context is cancelled; cleanup gets its own, bounded by
+	// the retry policy's OpTimeout per call. A second Ctrl-C still exits.
+	cleanupCtx := context.WithoutCancel(ctx)
+	var ids []string
+	seen := make(map[string]bool)
+	for _, inst := range manifest.Instances {
+		if inst.ID != "" && !inst.Adopted && !inst.gone() {
+			ids = append(ids, inst.ID)
+			seen[inst.ID] = true
+		}
+	}
+	tagged, err := listInstances(cleanupCtx, api, core.ListInstancesRequest{CompartmentId: common.String(manifest.Request.CompartmentID)}, retry)
+	if err != nil {
+		fmt.Printf("❌ Failed to look for untracked instances of run %s: %v\n", manifest.RunID, err)
+	}
+	for _, inst := range tagged {
+		switch inst.LifecycleState {
+		case core.InstanceLifecycleStateTerminating, core.InstanceLifecycleStateTerminated:
+			continue
+		}
+		if inst.FreeformTags[runIDTag] == manifest.RunID && !seen[*inst.Id] {
+			ids = append(ids, *inst.Id)
+			seen[*inst.Id] = true
+		}
+	}
+
+	fmt.Printf("Run %s interrupted, terminating the %d instance(s) it launched...\n", manifest.RunID, len(ids))
+	results := terminateInstances(cleanupCtx, api, ids, 10, 0, false, retry)
+	terminated := make(map[string]bool)
+	for _, result := range results {
+		if result.Error == nil {
+			terminated[result.InstanceID] = true
+		}
+	}
+	journal.setState(terminated, string(core.InstanceLifecycleStateTerminating))
+	fmt.Printf("Summary: %d/%d terminations accepted\n", len(terminated), len(ids))
+	if len(terminated) < len(ids) {
+		return fmt.Errorf("%w; %d of %d instances failed to terminate", interrupted, len(ids)-len(terminated), len(ids))
+	}
+	return interrupted
+}
+
+// launchWaves launches each wave and waits for it to settle before starting
+// the next, journaling and reporting every result. A single wave holds every
+// instance unless -wave-size split them.
+func launchWaves(ctx context.Context, api ComputeAPI, waves [][]InstanceConfig, parallel int, retry RetryPolicy, journal *runJournal, tally *launchTally) {
+	if len(waves) > 1 {
+		fmt.Printf("Launching in %d waves of up to %d\n", len(waves), len(waves[0]))
+	}
+	for w, wave := range waves {
+		if ctx.Err() != nil {
+			return
+		}
+		if len(waves) > 1 {
+			fmt.Printf("\n=== Wave %d/%d: launching %d instance(s) ===\n", w+1, len(waves), len(wave))
+		}
+		waveStarted := time.Now()
+
+		results := make(chan InstanceResult, len(wave))
+		go func(wave []InstanceConfig) {
+			launchInstances(ctx, api, wave, parallel, retry, journal, results)
+			close(results)
+		}(wave)
+
+		var waveResults []InstanceResult
+		for result := range results {
+			waveResults = append(waveResults, result)
+			journal.record(result)
+			tally.add(result)
+		}
+
+		if len(waves) > 1 {
+			printWaveSummary(w+1, len(waves), waveResults, time.Since(waveStarted))
+		}
+	}
+}
+
+// launchTally counts launch results for the run summary
+type launchTally struct {
+	succeeded   int
+	adopted     int
+	retried     int
+	fellBack    int
+	byShape     map[string]int
+	byPlacement map[string]int
+}
+
+func newLaunchTally() *launchTally {
+	return &launchTally{byShape: make(map[string]int), byPlacement: make(map[string]int)}
+}
+
+// add reports a result as it comes in and counts it
+func (t *launchTally) add(result InstanceResult) {
+	if result.Attempts > 1 {
+		t.retried++
+	}
+	if result.Fallbacks > 0 {
+		t.fellBack++
+	}
+	if result.Error != nil {
+		fmt.Printf("❌ Failed to create %s: %v\n", result.InstanceName, result.Error)
+		return
+	}
+	verb := "Successfully created"
+	if result.Adopted {
+		verb = "Adopted existing"
+		t.adopted++
+	}
+	if result.RunningAt != nil {
+		dur := result.RunningAt.Sub(result.LaunchStarted).Round(time.Second)
+		fmt.Printf("✓ %s %s (ID: %s) | shape: %s | placement: %s | launch: %s | running: %s | ready in: %s | attempts: %d\n",
+			verb,
+			result.InstanceName,
+			result.InstanceID,
+			result.Shape,
+			result.Placement,
+			result.LaunchStarted.Format(time.RFC3339),
+			result.RunningAt.Format(time.RFC3339),
+			dur,
+			result.Attempts,
+		)
+	} else {
+		fmt.Printf("✓ %s %s (ID: %s) | shape: %s | placement: %s | launch: %s | attempts: %d\n",
+			verb,
+			result.InstanceName,
+			result.InstanceID,
+			result.Shape,
+			result.Placement,
+			result.LaunchStarted.Format(time.RFC3339),
+			result.Attempts,
+		)
+	}
+	t.byPlacement[result.Placement.String()]++
+	t.byShape[result.Shape]++
+	t.succeeded++
+}
+
+// print reports the retry and fallback counts and where instances landed
+func (t *launchTally) print() {
+	if t.adopted > 0 {
+		fmt.Printf("Existing instances adopted instead of launched: %d\n", t.adopted)
+	}
+	if t.retried > 0 {
+		fmt.Printf("Launches that needed retries: %d\n", t.retried)
+	}
+	if t.fellBack > 0 {
+		fmt.Printf("Launches that fell back to another shape or placement: %d\n", t.fellBack)
+	}
+	printCounts("Instances per shape:", t.byShape)
+	printCounts("Instances per placement:", t.byPlacement)
+}
+
+type InstanceConfig struct {
+	Index         int // 1-based position in the run, matching the display name suffix
+	CompartmentID string
+	DisplayName   string
+	ImageID       string
+	SubnetID      string
+	Shapes        []ShapeOption // tried in priority order on capacity or limit errors
+	Placements    []Placement   // tried in order for each shape on capacity errors
+
+	AssignPublicIP bool
+	Metadata       map[string]string // ssh_authorized_keys, user_data and any custom keys
+	FreeformTags   map[string]string
+	DefinedTags    map[string]map[string]interface{}
+	RetryToken     string // seed for the retry token of each shape and placement tried
+}
+
+type InstanceResult struct {
+	Index         int
+	InstanceName  string
+	InstanceID    string
+	Error         error
+	LaunchStarted time.Time
+	RunningAt     *time.Time
+	Attempts      int                             // LaunchInstance calls made, including retries
+	Shape         string                          // shape the instance was launched with
+	Placement     Placement                       // where the instance landed, as reported by OCI
+	Fallbacks     int                             // shapes and placements skipped after capacity or limit errors
+	State         core.InstanceLifecycleStateEnum // last lifecycle state seen
+	Adopted       bool                            // found already running under this name rather than launched
+}
+
+// createInstance launches one instance, journals it as soon as OCI accepts the
+// launch, and waits for it to reach RUNNING
+func createInstance(ctx context.Context, client ComputeAPI, config InstanceConfig, retry RetryPolicy, journal *runJournal) InstanceResult {
+	launchStarted := time.Now().UTC()
+
+	// Create launch instance details
+	launchDetails := core.LaunchInstanceDetails{
+		CompartmentId: common.String(config.CompartmentID),
+		DisplayName:   common.String(config.DisplayName),
+		ImageId:       common.String(config.ImageID),
+		CreateVnicDetails: &core.CreateVnicDetails{
+			SubnetId:            common.String(config.SubnetID),
+			AssignPublicIp:      common.Bool(config.AssignPublicIP),
+			SkipSourceDestCheck: common.Bool(false),
+		},
+		Metadata:     config.Metadata,
+		FreeformTags: config.FreeformTags,
+		DefinedTags:  config.DefinedTags,
+	}
+
+	// Walk the shapes in priority order, trying every placement for each.
+	// A capacity error moves straight on to the next placement, or to the
+	// next shape once every placement is full; a limit error skips the rest
+	// of the current shape. Only the final target retries capacity errors
+	// like any other transient failure.
+	targets := launchTargets(config.Shapes, config.Placements)
+	var response core.LaunchInstanceResponse
+	var landed launchTarget
+	attempts := 0
+	fallbacks := 0
+	for i := 0; i < len(targets); i++ {
+		target := targets[i]
+		last := i == len(targets)-1
+		retryable := isRetryable
+		if !last {
+			retryable = func(err error) bool { return isRetryable(err) && !isOutOfCapacity(err) }
+		}
+
+		launchDetails.Shape = common.String(target.Shape.Shape)
+		launchDetails.ShapeConfig = target.Shape.shapeConfig()
+		launchDetails.AvailabilityDomain = common.String(target.Placement.AvailabilityDomain)
+		launchDetails.FaultDomain = nil
+		if target.Placement.FaultDomain != "" {
+			launchDetails.FaultDomain = common.String(target.Placement.FaultDomain)
+		}
+
+		// The retry token is the same for every attempt at this target, and
+		// for any later launch of this index by the same run, so a retry after
+		// a lost response or a resume cannot create a second instance.
+		request := core.LaunchInstanceRequest{
+			LaunchInstanceDetails: launchDetails,
+			OpcRetryToken:         common.String(retryToken(config.RetryToken, target.String())),
+		}
+
+		n, err := withRetryWhen(ctx, retry, "launch "+config.DisplayName+" as "+target.String(), retryable, func(ctx context.Context) error {
+			var err error
+			response, err = client.LaunchInstance(ctx, request)
+			return err
+		})
+		attempts += n
+		if err == nil {
+			landed = target
+			break
+		}
+		if !last && isOutOfCapacity(err) {
+			fmt.Printf("⤳ %s: out of capacity for %s, trying %s\n", config.DisplayName, target, targets[i+1])
+			fallbacks++
+			continue
+		}
+		if next := nextShape(targets, i); next < len(targets) && isLimitExceeded(err) {
+			fmt.Printf("⤳ %s: limit reached for %s, trying %s\n", config.DisplayName, target.Shape.Shape, targets[next])
+			fallbacks++
+			i = next - 1
+			continue
+		}
+		return InstanceResult{
+			Index:        config.Index,
+			InstanceName: config.DisplayName,
+			Error:        fmt.Errorf("launch failed as %s: %w", target, err),
+			Attempts:     attempts,
+			Fallbacks:    fallbacks,
+		}
+	}
+
+	result := InstanceResult{
+		Index:         config.Index,
+		InstanceName:  config.DisplayName,
+		InstanceID:    *response.Id,
+		LaunchStarted: launchStarted,
+		Attempts:      attempts,
+		Shape:         landed.Shape.Shape,
+		Placement: Placement{
+			AvailabilityDomain: derefString(response.AvailabilityDomain),
+			FaultDomain:        derefString(response.FaultDomain),
+		},
+		Fallbacks: fallbacks,
+		State:     response.LifecycleState,
+	}
+
+	journal.record(result)
+
+	return awaitRunning(ctx, client, result, retry)
+}
+
+// awaitRunning waits for a launched instance to reach RUNNING and fills in
+// when it got there, or why it never will
+func awaitRunning(ctx context.Context, client ComputeAPI, result InstanceResult, retry RetryPolicy) InstanceResult {
+	runningAt, state, err := waitForInstanceRunning(ctx, client, result.InstanceID, 10*time.Second, 30*time.Minute, retry)
+	if state != "" {
+		result.State = state
+	}
+	if err != nil {
+		result.Error = fmt.Errorf("launch wait failed: %w", err)
+		return result
+	}
+
+	result.RunningAt = &runningAt
+	return result
+}
+
+// waitForInstanceRunning polls until the instance is RUNNING and returns when
+// it was first seen running, along with the last lifecycle state seen
+func waitForInstanceRunning(ctx context.Context, client ComputeAPI, instanceID string, interval time.Duration, maxWait time.Duration, retry RetryPolicy) (time.Time, core.InstanceLifecycleStateEnum, error) {
+	ctxWait, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Immediate check before waiting
+	state, err := checkInstanceRunning(ctxWait, client, instanceID, retry)
+	for err == nil && state != core.InstanceLifecycleStateRunning {
+		select {
+		case <-ctxWait.Done():
+			return time.Time{}, state, fmt.Errorf("timeout waiting for running state: %w", ctxWait.Err())
+		case <-ticker.C:
+			var next core.InstanceLifecycleStateEnum
+			next, err = checkInstanceRunning(ctxWait, client, instanceID, retry)
+			if next != "" {
+				state = next
+			}
+		}
+	}
+	if err != nil {
+		return time.Time{}, state, err
+	}
+	return time.Now().UTC(), state, nil
+}
+
+// checkInstanceRunning returns the instance's lifecycle state, with an error
+// once it can no longer become RUNNING
+func checkInstanceRunning(ctx context.Context, client ComputeAPI, instanceID string, retry RetryPolicy) (core.InstanceLifecycleStateEnum, error) {
+	var resp core.GetInstanceResponse
+	_, err := withRetry(ctx, retry, "get "+instanceID, func(ctx context.Context) error {
+		var err error
+		resp, err = client.GetInstance(ctx, core.GetInstanceRequest{InstanceId: common.String(instanceID)})
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("get instance failed: %w", err)
+	}
+
+	state := resp.Instance.LifecycleState
+	switch state {
+	case core.InstanceLifecycleStateTerminated, core.InstanceLifecycleStateTerminating, core.InstanceLifecycleStateStopped:
+		return state, fmt.Errorf("instance entered terminal state: %s", state)
+	default:
+		return state, nil
+	}
+}
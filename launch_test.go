@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+// testJournal returns a journal writing to a manifest in a temporary directory
+func testJournal(t *testing.T) *runJournal {
+	t.Helper()
+	journal, err := newRunJournal(filepath.Join(t.TempDir(), "run.json"), &Manifest{Version: 1, RunID: "run-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return journal
+}
+
+func testInstanceConfig(shapes []string, ads ...string) InstanceConfig {
+	config := InstanceConfig{
+		Index:         1,
+		CompartmentID: "ocid1.compartment.test",
+		DisplayName:   "web-1",
+		ImageID:       "ocid1.image.test",
+		SubnetID:      "ocid1.subnet.test",
+		RetryToken:    "run-a/1",
+	}
+	for _, shape := range shapes {
+		config.Shapes = append(config.Shapes, ShapeOption{Shape: shape})
+	}
+	for _, ad := range ads {
+		config.Placements = append(config.Placements, Placement{AvailabilityDomain: ad})
+	}
+	return config
+}
+
+func TestCreateInstanceMovesOnWhenAnADIsFull(t *testing.T) {
+	b := ocifake.New()
+	b.SetCapacity(ocifake.Capacity{AvailabilityDomain: "AD-1", Limit: 0})
+	journal := testJournal(t)
+
+	result := createInstance(context.Background(), b, testInstanceConfig([]string{"VM.Standard2.1"}, "AD-1", "AD-2"), testRetry, journal)
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.Placement.AvailabilityDomain != "AD-2" || result.Fallbacks != 1 || result.Attempts != 2 {
+		t.Errorf("landed in %s after %d fallbacks and %d attempts, want AD-2, 1 and 2", result.Placement, result.Fallbacks, result.Attempts)
+	}
+	if result.State != core.InstanceLifecycleStateRunning || result.RunningAt == nil {
+		t.Errorf("state %s, running at %v; want RUNNING with a time", result.State, result.RunningAt)
+	}
+	if n := len(journal.manifest.Instances); n != 1 || journal.manifest.Instances[0].ID != result.InstanceID {
+		t.Errorf("journal holds %+v, want the launched instance", journal.manifest.Instances)
+	}
+}
+
+func TestCreateInstanceSkipsAShapeAtItsLimit(t *testing.T) {
+	b := ocifake.New()
+	b.InjectError(ocifake.OpLaunchInstance, ocifake.LimitExceeded(), 1)
+
+	config := testInstanceConfig([]string{"VM.Standard2.1", "VM.Standard2.2"}, "AD-1", "AD-2")
+	result := createInstance(context.Background(), b, config, testRetry, testJournal(t))
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.Shape != "VM.Standard2.2" || result.Placement.AvailabilityDomain != "AD-1" || result.Fallbacks != 1 {
+		t.Errorf("launched as %s in %s after %d fallbacks, want VM.Standard2.2 in AD-1 after 1", result.Shape, result.Placement, result.Fallbacks)
+	}
+}
+
+func TestCreateInstanceRetriesCapacityOnTheLastTarget(t *testing.T) {
+	b := ocifake.New()
+	b.InjectError(ocifake.OpLaunchInstance, ocifake.OutOfCapacity(), 2)
+
+	result := createInstance(context.Background(), b, testInstanceConfig([]string{"VM.Standard2.1"}, "AD-1"), testRetry, testJournal(t))
+	if result.Error != nil {
+		t.Fatal(result.Error)
+	}
+	if result.Attempts != 3 || result.Fallbacks != 0 {
+		t.Errorf("took %d attempts and %d fallbacks, want 3 and 0", result.Attempts, result.Fallbacks)
+	}
+	if n := len(b.Instances()); n != 1 {
+		t.Errorf("%d instances launched, want 1", n)
+	}
+}
+
+func TestCreateInstanceFailsWhenNothingFits(t *testing.T) {
+	b := ocifake.New()
+	b.SetCapacity(ocifake.Capacity{Limit: 0})
+	journal := testJournal(t)
+
+	config := testInstanceConfig([]string{"VM.Standard2.1", "VM.Standard2.2"}, "AD-1", "AD-2")
+	result := createInstance(context.Background(), b, config, testRetry, journal)
+	if result.Error == nil || result.InstanceID != "" {
+		t.Fatalf("got instance %q, error %v; want a launch failure", result.InstanceID, result.Error)
+	}
+	// Three targets give up at once; the last retries to the policy's limit
+	if result.Fallbacks != 3 || result.Attempts != 3+testRetry.MaxAttempts {
+		t.Errorf("%d fallbacks and %d attempts, want 3 and %d", result.Fallbacks, result.Attempts, 3+testRetry.MaxAttempts)
+	}
+	if n := len(journal.manifest.Instances); n != 0 {
+		t.Errorf("journal holds %d instances, want none", n)
+	}
+}
+
+func TestWaitForInstanceRunningFollowsLifecycle(t *testing.T) {
+	clock := ocifake.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := ocifake.New(ocifake.WithClock(clock.Now), ocifake.WithTimeline(ocifake.Timeline{Provisioning: time.Minute}))
+	id := launchFake(t, b, "web-1", "")
+
+	type outcome struct {
+		state core.InstanceLifecycleStateEnum
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		_, state, err := waitForInstanceRunning(context.Background(), b, id, time.Millisecond, 10*time.Second, testRetry)
+		done <- outcome{state, err}
+	}()
+
+	// Let it see PROVISIONING at least twice before the instance comes up
+	for b.Calls(ocifake.OpGetInstance) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	got := <-done
+	if got.err != nil || got.state != core.InstanceLifecycleStateRunning {
+		t.Errorf("wait ended in %s with %v, want RUNNING", got.state, got.err)
+	}
+}
+
+func TestWaitForInstanceRunningStopsAtATerminalState(t *testing.T) {
+	b := ocifake.New()
+	id := launchFake(t, b, "web-1", "")
+	if err := b.SetInstanceState(id, core.InstanceLifecycleStateStopped); err != nil {
+		t.Fatal(err)
+	}
+
+	_, state, err := waitForInstanceRunning(context.Background(), b, id, time.Millisecond, 10*time.Second, testRetry)
+	if err == nil || state != core.InstanceLifecycleStateStopped {
+		t.Errorf("wait ended in %s with %v, want an error at STOPPED", state, err)
+	}
+}
+
+func TestWaitForInstanceRunningTimesOut(t *testing.T) {
+	b := ocifake.New(ocifake.WithTimeline(ocifake.Timeline{Provisioning: time.Hour}))
+	id := launchFake(t, b, "web-1", "")
+
+	_, state, err := waitForInstanceRunning(context.Background(), b, id, time.Millisecond, 20*time.Millisecond, testRetry)
+	if err == nil || state != core.InstanceLifecycleStateProvisioning {
+		t.Errorf("wait ended in %s with %v, want a timeout at PROVISIONING", state, err)
+	}
+}
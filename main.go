@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// Exit codes shared by every command
+const (
+	exitOK      = 0
+	exitFailure = 1 // an API call failed or some instances could not be launched or terminated
+	exitUsage   = 2 // the command line was invalid
+
+	exitInterrupted = 130 // stopped by Ctrl-C or SIGTERM, as shells report for SIGINT
+)
+
+// errUsage is returned once a command line mistake has been reported along
+// with the command's usage
+var errUsage = errors.New("invalid usage")
+
+// command is a top-level subcommand
+type command struct {
+	Name    string
+	Summary string
+	Run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"launch", "Launch standalone instances across shapes, ADs and fault domains", runLaunch},
+	{"resume", "Finish an interrupted launch run from its manifest", runResume},
+	{"terminate", "Terminate the instances listed in an output file", runTerminate},
+	{"pool", "Create, scale, list, terminate or detach from an instance pool", runPool},
+	{"cleanup", "Delete resources left behind by earlier runs", runCleanup},
+	{"sweep", "Find resources earlier runs left unused and delete them", runSweep},
+	{"reap", "Terminate instances and pools whose expiry tag has passed", runReap},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run dispatches to the subcommand named by args[0] and returns the exit code
+func run(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+
+	name, rest := args[0], args[1:]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(rest) == 0 {
+			printUsage(os.Stdout)
+			return exitOK
+		}
+		// "help launch" is the same as "launch -h"
+		name, rest = rest[0], []string{"-h"}
+	}
+	// Flags with no command are the original launcher invocation
+	if strings.HasPrefix(name, "-") {
+		name, rest = "launch", args
+	}
+
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			ctx, cancel := interruptContext()
+			defer cancel()
+			return exitCode(cmd.Run(ctx, rest))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+// interruptContext returns a context that is cancelled on the first Ctrl-C or
+// SIGTERM, so commands can stop waiting, record what they have done and clean
+// up. A second Ctrl-C exits immediately.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "\n%s received, stopping; press Ctrl-C again to exit immediately\n", sig)
+			signal.Stop(signals)
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(signals)
+		}
+	}()
+	return ctx, cancel
+}
+
+// exitCode reports err and maps it to the process exit code
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, context.Canceled):
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitInterrupted
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFailure
+	}
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: oci-insta-scale <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'oci-insta-scale <command> -h' for the flags a command takes.")
+}
+
+// newFlagSet returns a flag set for a subcommand whose usage shows the
+// command line and summary before the flags
+func newFlagSet(name, args, summary string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage: oci-insta-scale %s [flags]%s\n\n%s\n\nFlags:\n", name, args, summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args into fs. The flag package has already printed the
+// problem and usage when it fails, so only errUsage or flag.ErrHelp is returned.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// usageErrorf reports a command line mistake followed by the command's usage
+func usageErrorf(fs *flag.FlagSet, format string, args ...interface{}) error {
+	fmt.Fprintf(fs.Output(), "Error: "+format+"\n\n", args...)
+	fs.Usage()
+	return errUsage
+}
+
+// commonFlags are the config file, endpoint, retry and rate limit flags every
+// command takes
+type commonFlags struct {
+	fs         *flag.FlagSet
+	configFile *string
+	endpoint   *string
+	retry      *RetryPolicy
+	limits     *RateLimits
+}
+
+// registerCommonFlags adds the common flags to fs. defaultConfig is the
+// config file read when -config is not given; empty means none.
+func registerCommonFlags(fs *flag.FlagSet, defaultConfig string) *commonFlags {
+	return &commonFlags{
+		fs:         fs,
+		configFile: fs.String("config", defaultConfig, "YAML or JSON config file (see config.example.yaml); flags override its values"),
+		endpoint:   fs.String("endpoint", "", "Override the OCI API endpoint (e.g. http://127.0.0.1:9090 for ocifake)"),
+		retry:      registerRetryFlags(fs),
+		limits:     registerRateLimitFlags(fs),
+	}
+}
+
+// load reads the config file, if there is one, and applies the common flags
+// given on the command line over it
+func (f *commonFlags) load() (*Config, error) {
+	config := &Config{Retry: DefaultRetryPolicy(), RateLimit: DefaultRateLimits(), Sweep: DefaultSweepPolicy(), Reap: DefaultReapPolicy()}
+	if *f.configFile != "" {
+		loaded, err := LoadConfig(*f.configFile)
+		if err != nil {
+			return nil, err
+		}
+		config = loaded
+	}
+
+	set := flagsSet(f.fs)
+	if set["endpoint"] {
+		config.Endpoint = *f.endpoint
+	}
+	if set["max-attempts"] {
+		config.Retry.MaxAttempts = f.retry.MaxAttempts
+	}
+	if set["retry-base-delay"] {
+		config.Retry.BaseDelay = f.retry.BaseDelay
+	}
+	if set["retry-max-delay"] {
+		config.Retry.MaxDelay = f.retry.MaxDelay
+	}
+	if set["op-timeout"] {
+		config.Retry.OpTimeout = f.retry.OpTimeout
+	}
+	if set["write-rps"] {
+		config.RateLimit.MutatingRPS = f.limits.MutatingRPS
+	}
+	if set["write-burst"] {
+		config.RateLimit.MutatingBurst = f.limits.MutatingBurst
+	}
+	if set["read-rps"] {
+		config.RateLimit.ReadRPS = f.limits.ReadRPS
+	}
+	if set["read-burst"] {
+		config.RateLimit.ReadBurst = f.limits.ReadBurst
+	}
+	if config.Retry.MaxAttempts < 1 {
+		return nil, fmt.Errorf("max-attempts must be at least 1")
+	}
+	return config, nil
+}
+
+// flagsSet returns the names of the flags given on the command line
+func flagsSet(fs *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
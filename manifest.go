@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// manifestVersion is the run manifest format written by this build. Readers
+// reject manifests from a newer format rather than guess at their meaning.
+const manifestVersion = 1
+
+// Manifest records a launch run: what was asked for and what became of every
+// instance, including the ones that failed. launch writes it to -output, and
+// terminate and the other commands read it back.
+type Manifest struct {
+	Version    int                `json:"version"`
+	RunID      string             `json:"run_id"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	ResumedAt  []time.Time        `json:"resumed_at,omitempty"`
+	CreatedBy  string             `json:"created_by,omitempty"` // user OCID the run launched as, if known
+	ExpiresAt  *time.Time         `json:"expires_at,omitempty"` // when the run's instances may be reaped
+	Request    ManifestRequest    `json:"request"`
+	Instances  []ManifestInstance `json:"instances"`
+}
+
+// ManifestRequest is the launch configuration after flags and -config were
+// merged. It holds everything needed to launch any instance of the run again.
+type ManifestRequest struct {
+	Instances      int                               `json:"instances"`
+	Name           string                            `json:"name"`
+	CompartmentID  string                            `json:"compartment_id"`
+	ImageID        string                            `json:"image_id"`
+	SubnetID       string                            `json:"subnet_id"`
+	Shapes         []ShapeOption                     `json:"shapes"`
+	Strategy       string                            `json:"placement_strategy"`
+	Placements     []Placement                       `json:"placements"`
+	Plan           []Placement                       `json:"plan,omitempty"` // spread strategy: the planned placement of each instance, by index
+	AssignPublicIP bool                              `json:"assign_public_ip"`
+	Metadata       map[string]string                 `json:"metadata,omitempty"`
+	FreeformTags   map[string]string                 `json:"freeform_tags,omitempty"`
+	DefinedTags    map[string]map[string]interface{} `json:"defined_tags,omitempty"`
+	Parallel       int                               `json:"parallel,omitempty"`
+	WaveSize       int                               `json:"wave_size,omitempty"`
+	ConfigFile     string                            `json:"config_file,omitempty"`
+}
+
+// ManifestInstance is the outcome for one instance of the run
+type ManifestInstance struct {
+	Index       int        `json:"index"` // 1-based, matching the display name suffix
+	DisplayName string     `json:"display_name"`
+	ID          string     `json:"id,omitempty"` // empty if no instance was created
+	Shape       string     `json:"shape,omitempty"`
+	Placement   *Placement `json:"placement,omitempty"`
+	LaunchedAt  *time.Time `json:"launched_at,omitempty"`
+	RunningAt   *time.Time `json:"running_at,omitempty"`
+	Attempts    int        `json:"attempts"`
+	Fallbacks   int        `json:"fallbacks,omitempty"`
+	State       string     `json:"state"` // last lifecycle state seen, or FAILED if the launch never succeeded
+	Error       string     `json:"error,omitempty"`
+	Adopted     bool       `json:"adopted,omitempty"` // an existing instance was found instead of launching one
+}
+
+// stateFailed marks a manifest entry whose launch never produced an instance
+const stateFailed = "FAILED"
+
+// instanceConfig returns the launch settings for the instance at index
+// (1-based), tagged with the run's standard tags. Its retry token seed depends
+// only on the run ID and index, so launching the same index of the same run
+// again cannot make a second instance.
+func (m *Manifest) instanceConfig(index int) InstanceConfig {
+	r := m.Request
+	placements := r.Placements
+	if index <= len(r.Plan) {
+		placements = withFallbacks(r.Plan[index-1], r.Placements)
+	}
+	return InstanceConfig{
+		Index:          index,
+		CompartmentID:  r.CompartmentID,
+		DisplayName:    fmt.Sprintf("%s-%d", r.Name, index),
+		ImageID:        r.ImageID,
+		Shapes:         r.Shapes,
+		SubnetID:       r.SubnetID,
+		Placements:     placements,
+		AssignPublicIP: r.AssignPublicIP,
+		Metadata:       r.Metadata,
+		FreeformTags:   runTags(m.RunID, m.CreatedBy, m.StartedAt, m.ExpiresAt, r.FreeformTags),
+		DefinedTags:    r.DefinedTags,
+		RetryToken:     retryToken(m.RunID, strconv.Itoa(index)),
+	}
+}
+
+// gone reports whether the entry's instance was seen terminating or terminated,
+// so a resumed run has to launch its index again
+func (e ManifestInstance) gone() bool {
+	return e.State == string(core.InstanceLifecycleStateTerminating) || e.State == string(core.InstanceLifecycleStateTerminated)
+}
+
+// newRunID returns an ID for a launch run that sorts by start time
+func newRunID(now time.Time) string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		// Uniqueness within the same second is a nicety, not a requirement
+		return "run-" + now.UTC().Format("20060102-150405")
+	}
+	return "run-" + now.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// manifestInstance converts a launch result into its manifest entry
+func manifestInstance(result InstanceResult) ManifestInstance {
+	entry := ManifestInstance{
+		Index:       result.Index,
+		DisplayName: result.InstanceName,
+		ID:          result.InstanceID,
+		Shape:       result.Shape,
+		RunningAt:   result.RunningAt,
+		Attempts:    result.Attempts,
+		Fallbacks:   result.Fallbacks,
+		State:       string(result.State),
+		Adopted:     result.Adopted,
+	}
+	if result.InstanceID != "" {
+		placement := result.Placement
+		launchedAt := result.LaunchStarted
+		entry.Placement = &placement
+		entry.LaunchedAt = &launchedAt
+	} else {
+		entry.State = stateFailed
+	}
+	if result.Error != nil {
+		entry.Error = result.Error.Error()
+	}
+	return entry
+}
+
+// writeManifest writes m to filename as indented JSON. The file is replaced
+// atomically so an interrupted write never leaves a truncated manifest.
+func writeManifest(filename string, m *Manifest) error {
+	sort.Slice(m.Instances, func(i, j int) bool { return m.Instances[i].Index < m.Instances[j].Index })
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// runJournal keeps the run manifest on disk up to date as results arrive, so a
+// launch that is killed part way through can be resumed from it
+type runJournal struct {
+	mu       sync.Mutex
+	path     string
+	manifest *Manifest
+}
+
+// newRunJournal writes m to path and returns a journal that keeps it current
+func newRunJournal(path string, m *Manifest) (*runJournal, error) {
+	if err := writeManifest(path, m); err != nil {
+		return nil, err
+	}
+	return &runJournal{path: path, manifest: m}, nil
+}
+
+// record adds or replaces the manifest entry for result's index and rewrites
+// the manifest. A failed write is reported but does not stop the run.
+func (j *runJournal) record(result InstanceResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry := manifestInstance(result)
+	replaced := false
+	for i := range j.manifest.Instances {
+		if j.manifest.Instances[i].Index == entry.Index {
+			j.manifest.Instances[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		j.manifest.Instances = append(j.manifest.Instances, entry)
+	}
+	if err := writeManifest(j.path, j.manifest); err != nil {
+		fmt.Printf("❌ Failed to update run manifest %s: %v\n", j.path, err)
+	}
+}
+
+// setState records state for the instances in ids and rewrites the manifest
+func (j *runJournal) setState(ids map[string]bool, state string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := range j.manifest.Instances {
+		if ids[j.manifest.Instances[i].ID] {
+			j.manifest.Instances[i].State = state
+		}
+	}
+	if err := writeManifest(j.path, j.manifest); err != nil {
+		fmt.Printf("❌ Failed to update run manifest %s: %v\n", j.path, err)
+	}
+}
+
+// finish stamps the run's finish time and writes the manifest a last time
+func (j *runJournal) finish() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	finished := time.Now().UTC()
+	j.manifest.FinishedAt = &finished
+	return writeManifest(j.path, j.manifest)
+}
+
+// readManifest reads a run manifest
+func readManifest(filename string) (*Manifest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(data)
+}
+
+func parseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid run manifest: %w", err)
+	}
+	if m.Version < 1 || m.Version > manifestVersion {
+		return nil, fmt.Errorf("unsupported run manifest version %d (this build reads up to %d)", m.Version, manifestVersion)
+	}
+	return &m, nil
+}
+
+// readInstancesFromFile returns the instance OCIDs in a run manifest, or in
+// the legacy format of one OCID per line with # comments
+func readInstancesFromFile(filename string) ([]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		m, err := parseManifest(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		var instanceIDs []string
+		for _, inst := range m.Instances {
+			if inst.ID != "" {
+				instanceIDs = append(instanceIDs, inst.ID)
+			}
+		}
+		return instanceIDs, nil
+	}
+
+	var instanceIDs []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			instanceIDs = append(instanceIDs, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return instanceIDs, nil
+}
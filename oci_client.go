@@ -0,0 +1,497 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+// OCIClient wraps OCI SDK clients. The clients are held behind interfaces so
+// an in-memory backend such as ocifake.Backend can stand in for a real tenancy.
+type OCIClient struct {
+	ComputeClient           ComputeAPI
+	ComputeManagementClient ComputeManagementAPI
+	BlockstorageClient      BlockstorageAPI
+	IdentityClient          IdentityAPI
+	Config                  *Config
+	UserOCID                string // the user the clients authenticate as, if known, for the created-by tag
+}
+
+// NewOCIClient creates the Compute, ComputeManagement, Block Storage and
+// Identity clients the commands use, authenticated as the config describes
+// and sharing one rate limiter so the configured rates apply to the tool as a
+// whole
+func NewOCIClient(config *Config) (*OCIClient, error) {
+	configProvider, err := config.configurationProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create compute client
+	computeClient, err := core.NewComputeClientWithConfigurationProvider(configProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compute client: %w", err)
+	}
+
+	// Create compute management client
+	computeMgmtClient, err := core.NewComputeManagementClientWithConfigurationProvider(configProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compute management client: %w", err)
+	}
+
+	// Create block storage client, used to clean up boot volumes
+	blockstorageClient, err := core.NewBlockstorageClientWithConfigurationProvider(configProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create block storage client: %w", err)
+	}
+
+	// Create identity client, used to find the compartments under another
+	identityClient, err := identity.NewIdentityClientWithConfigurationProvider(configProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity client: %w", err)
+	}
+
+	if config.Endpoint != "" {
+		computeClient.Host = config.Endpoint
+		computeMgmtClient.Host = config.Endpoint
+		blockstorageClient.Host = config.Endpoint
+		identityClient.Host = config.Endpoint
+	}
+
+	limiter := newAPILimiter(config.RateLimit)
+
+	// Instance and resource principals have no user to record
+	userOCID, _ := configProvider.UserOCID()
+
+	return &OCIClient{
+		ComputeClient:           newRateLimitedCompute(computeClient, limiter),
+		ComputeManagementClient: newRateLimitedComputeManagement(computeMgmtClient, limiter),
+		BlockstorageClient:      newRateLimitedBlockstorage(blockstorageClient, limiter),
+		IdentityClient:          newRateLimitedIdentity(identityClient, limiter),
+		Config:                  config,
+		UserOCID:                userOCID,
+	}, nil
+}
+
+// retry runs an API call under the configured retry policy
+func (c *OCIClient) retry(ctx context.Context, name string, op func(ctx context.Context) error) error {
+	return c.retryWhen(ctx, name, isRetryable, op)
+}
+
+// retryWhen is retry with a caller-supplied test for retryable errors
+func (c *OCIClient) retryWhen(ctx context.Context, name string, retryable func(error) bool, op func(ctx context.Context) error) error {
+	policy := DefaultRetryPolicy()
+	if c.Config != nil {
+		policy = c.Config.Retry
+	}
+	_, err := withRetryWhen(ctx, policy, name, retryable, op)
+	return err
+}
+
+// CreateInstancePool creates an instance pool with the specified configuration.
+// If the pool cannot be created with the primary shape because of capacity or
+// service limits, each fallback shape is tried in turn, deleting the instance
+// configuration made for the shape that failed. It returns the pool and the
+// shape it was created with. The pool, its instance configuration and its
+// instances carry the run's standard tags. Retry tokens and default display
+// names are derived from runID, so creating a pool again with the same run ID
+// sends the same requests and returns the resources the first attempt made
+// rather than duplicating them.
+func (c *OCIClient) CreateInstancePool(ctx context.Context, config *Config, runID string) (*core.InstancePool, string, error) {
+	createdAt := time.Now().UTC()
+	tags := runTags(runID, c.UserOCID, createdAt, expiryAfter(createdAt, config.TTL), nil)
+
+	// Step 1: Build placement configurations
+	placementConfigs := make([]core.CreateInstancePoolPlacementConfigurationDetails, 0, len(config.InstancePool.Placement))
+	for _, placement := range config.InstancePool.Placement {
+		placementConfig := core.CreateInstancePoolPlacementConfigurationDetails{
+			AvailabilityDomain: common.String(placement.AvailabilityDomain),
+			PrimarySubnetId:    common.String(config.InstancePool.InstanceConfiguration.SubnetID),
+		}
+		if len(placement.FaultDomains) > 0 {
+			placementConfig.FaultDomains = placement.FaultDomains
+		}
+		placementConfigs = append(placementConfigs, placementConfig)
+	}
+
+	// Step 2: Build load balancer configurations (if any)
+	var lbAttachments []core.AttachLoadBalancerDetails
+	for _, lb := range config.InstancePool.LoadBalancers {
+		lbAttachment := core.AttachLoadBalancerDetails{
+			LoadBalancerId: common.String(lb.LoadBalancerID),
+			BackendSetName: common.String(lb.BackendSetName),
+			Port:           common.Int(lb.Port),
+			VnicSelection:  common.String(lb.VnicSelection),
+		}
+		lbAttachments = append(lbAttachments, lbAttachment)
+	}
+
+	displayName := config.InstancePool.DisplayName
+	if displayName == "" {
+		displayName = "instance-pool-" + runID
+	}
+
+	// Step 3: Create an instance configuration and the pool for each shape
+	// until one succeeds. Capacity errors are not retried on a shape that has
+	// a fallback after it.
+	shapes := config.InstancePool.InstanceConfiguration.Shapes()
+	for i, shape := range shapes {
+		last := i == len(shapes)-1
+		retryable := isRetryable
+		if !last {
+			retryable = func(err error) bool { return isRetryable(err) && !isOutOfCapacity(err) }
+		}
+
+		fmt.Printf("Creating instance configuration for shape %s...\n", shape.Shape)
+		instanceConfig, err := c.createInstanceConfiguration(ctx, config, shape, runID, tags)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to create instance configuration: %w", err)
+		}
+		fmt.Printf("Instance configuration created: %s\n", *instanceConfig.Id)
+
+		fmt.Println("Creating instance pool...")
+		pool, err := c.createInstancePool(ctx, config, displayName, instanceConfig, placementConfigs, lbAttachments, retryable, tags, retryToken(runID, "instance-pool", shape.Shape))
+		if err == nil {
+			return pool, shape.Shape, nil
+		}
+		if !(isOutOfCapacity(err) || isLimitExceeded(err)) {
+			return nil, "", err
+		}
+		// The pool was refused, so nothing uses the configuration made for it
+		c.discardInstanceConfiguration(ctx, *instanceConfig.Id)
+		if last {
+			return nil, "", err
+		}
+		fmt.Printf("⤳ Shape %s unavailable (%s), trying %s\n", shape.Shape, describeError(err), shapes[i+1].Shape)
+	}
+	return nil, "", fmt.Errorf("no shapes configured")
+}
+
+// createInstancePool creates the pool itself from an instance configuration,
+// tagged with the run's standard tags
+func (c *OCIClient) createInstancePool(ctx context.Context, config *Config, displayName string, instanceConfig *core.InstanceConfiguration,
+	placementConfigs []core.CreateInstancePoolPlacementConfigurationDetails, lbAttachments []core.AttachLoadBalancerDetails, retryable func(error) bool, tags map[string]string, token string) (*core.InstancePool, error) {
+	createPoolReq := core.CreateInstancePoolRequest{
+		CreateInstancePoolDetails: core.CreateInstancePoolDetails{
+			CompartmentId:           common.String(config.CompartmentID),
+			InstanceConfigurationId: instanceConfig.Id,
+			PlacementConfigurations: placementConfigs,
+			Size:                    common.Int(config.InstancePool.Size),
+			DisplayName:             common.String(displayName),
+			LoadBalancers:           lbAttachments,
+			FreeformTags:            tags,
+		},
+		OpcRetryToken: common.String(token),
+	}
+
+	var poolResp core.CreateInstancePoolResponse
+	err := c.retryWhen(ctx, "CreateInstancePool", retryable, func(ctx context.Context) error {
+		var err error
+		poolResp, err = c.ComputeManagementClient.CreateInstancePool(ctx, createPoolReq)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instance pool: %w", err)
+	}
+
+	return &poolResp.InstancePool, nil
+}
+
+// createInstanceConfiguration creates an instance configuration from the
+// config, launching instances with the given shape. The configuration carries
+// the run's standard tags, and the instances it launches carry them over the
+// config's own tags.
+func (c *OCIClient) createInstanceConfiguration(ctx context.Context, config *Config, shape ShapeChoice, runID string, tags map[string]string) (*core.InstanceConfiguration, error) {
+	instConfig := config.InstancePool.InstanceConfiguration
+	token := retryToken(runID, "instance-configuration", shape.Shape)
+
+	displayName := instConfig.DisplayName
+	if displayName == "" {
+		displayName = "instance-config-" + runID
+	}
+
+	// Build instance details
+	instanceDetails := core.ComputeInstanceDetails{
+		LaunchDetails: &core.InstanceConfigurationLaunchInstanceDetails{
+			CompartmentId: common.String(config.CompartmentID),
+			Shape:         common.String(shape.Shape),
+			CreateVnicDetails: &core.InstanceConfigurationCreateVnicDetails{
+				SubnetId:       common.String(instConfig.SubnetID),
+				AssignPublicIp: common.Bool(instConfig.AssignPublicIP),
+			},
+			SourceDetails: &core.InstanceConfigurationInstanceSourceViaImageDetails{
+				ImageId: common.String(instConfig.ImageID),
+			},
+		},
+	}
+
+	// Add shape config for flexible shapes
+	if shape.ShapeConfig != (ShapeConfig{}) {
+		// Validate has already checked the baseline
+		baseline, _ := parseBaseline(shape.ShapeConfig.BaselineOcpuUtilization)
+		instanceDetails.LaunchDetails.ShapeConfig = &core.InstanceConfigurationLaunchInstanceShapeConfigDetails{
+			BaselineOcpuUtilization: core.InstanceConfigurationLaunchInstanceShapeConfigDetailsBaselineOcpuUtilizationEnum(baseline),
+		}
+		if shape.ShapeConfig.Ocpus > 0 {
+			instanceDetails.LaunchDetails.ShapeConfig.Ocpus = common.Float32(shape.ShapeConfig.Ocpus)
+		}
+		if shape.ShapeConfig.MemoryInGBs > 0 {
+			instanceDetails.LaunchDetails.ShapeConfig.MemoryInGBs = common.Float32(shape.ShapeConfig.MemoryInGBs)
+		}
+		if shape.ShapeConfig.Nvmes > 0 {
+			instanceDetails.LaunchDetails.ShapeConfig.Nvmes = common.Int(shape.ShapeConfig.Nvmes)
+		}
+	}
+
+	// Add SSH keys
+	if instConfig.SSHAuthorizedKeys != "" {
+		instanceDetails.LaunchDetails.Metadata = map[string]string{
+			"ssh_authorized_keys": instConfig.SSHAuthorizedKeys,
+		}
+	}
+
+	// Add user data
+	if instConfig.UserData != "" {
+		if instanceDetails.LaunchDetails.Metadata == nil {
+			instanceDetails.LaunchDetails.Metadata = make(map[string]string)
+		}
+		instanceDetails.LaunchDetails.Metadata["user_data"] = instConfig.UserData
+	}
+
+	// Add custom metadata
+	if len(instConfig.Metadata) > 0 {
+		if instanceDetails.LaunchDetails.Metadata == nil {
+			instanceDetails.LaunchDetails.Metadata = make(map[string]string)
+		}
+		for k, v := range instConfig.Metadata {
+			instanceDetails.LaunchDetails.Metadata[k] = v
+		}
+	}
+
+	// Add tags
+	instanceDetails.LaunchDetails.FreeformTags = make(map[string]string)
+	for k, v := range instConfig.FreeformTags {
+		instanceDetails.LaunchDetails.FreeformTags[k] = v
+	}
+	for k, v := range tags {
+		instanceDetails.LaunchDetails.FreeformTags[k] = v
+	}
+	if len(instConfig.DefinedTags) > 0 {
+		instanceDetails.LaunchDetails.DefinedTags = instConfig.DefinedTags
+	}
+
+	// Create the instance configuration
+	createConfigReq := core.CreateInstanceConfigurationRequest{
+		CreateInstanceConfiguration: core.CreateInstanceConfigurationDetails{
+			CompartmentId:   common.String(config.CompartmentID),
+			DisplayName:     common.String(displayName),
+			InstanceDetails: instanceDetails,
+			FreeformTags:    tags,
+		},
+		OpcRetryToken: common.String(token),
+	}
+
+	var configResp core.CreateInstanceConfigurationResponse
+	err := c.retry(ctx, "CreateInstanceConfiguration", func(ctx context.Context) error {
+		var err error
+		configResp, err = c.ComputeManagementClient.CreateInstanceConfiguration(ctx, createConfigReq)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instance configuration: %w", err)
+	}
+
+	return &configResp.InstanceConfiguration, nil
+}
+
+// ListInstanceConfigurations lists every instance configuration in a
+// compartment
+func (c *OCIClient) ListInstanceConfigurations(ctx context.Context, compartmentID string) ([]core.InstanceConfigurationSummary, error) {
+	listReq := core.ListInstanceConfigurationsRequest{
+		CompartmentId: common.String(compartmentID),
+	}
+
+	var configs []core.InstanceConfigurationSummary
+	for {
+		var resp core.ListInstanceConfigurationsResponse
+		err := c.retry(ctx, "ListInstanceConfigurations", func(ctx context.Context) error {
+			var err error
+			resp, err = c.ComputeManagementClient.ListInstanceConfigurations(ctx, listReq)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instance configurations: %w", err)
+		}
+		configs = append(configs, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return configs, nil
+		}
+		listReq.Page = resp.OpcNextPage
+	}
+}
+
+// DeleteInstanceConfiguration deletes an instance configuration. The service
+// refuses while a pool still uses it.
+func (c *OCIClient) DeleteInstanceConfiguration(ctx context.Context, instanceConfigurationID string) error {
+	deleteReq := core.DeleteInstanceConfigurationRequest{
+		InstanceConfigurationId: common.String(instanceConfigurationID),
+	}
+
+	err := c.retry(ctx, "DeleteInstanceConfiguration", func(ctx context.Context) error {
+		_, err := c.ComputeManagementClient.DeleteInstanceConfiguration(ctx, deleteReq)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete instance configuration: %w", err)
+	}
+
+	return nil
+}
+
+// discardInstanceConfiguration deletes an instance configuration that no pool
+// was created from, reporting a failure rather than returning it so the
+// caller's own error or fallback goes ahead
+func (c *OCIClient) discardInstanceConfiguration(ctx context.Context, instanceConfigurationID string) {
+	if err := c.DeleteInstanceConfiguration(ctx, instanceConfigurationID); err != nil {
+		fmt.Printf("❌ Unused instance configuration %s was not deleted (remove with cleanup instance-configs): %v\n", instanceConfigurationID, err)
+		return
+	}
+	fmt.Printf("Deleted unused instance configuration %s\n", instanceConfigurationID)
+}
+
+// ScaleInstancePool scales an existing instance pool to a new size
+func (c *OCIClient) ScaleInstancePool(ctx context.Context, instancePoolID string, newSize int) error {
+	updateReq := core.UpdateInstancePoolRequest{
+		InstancePoolId: common.String(instancePoolID),
+		UpdateInstancePoolDetails: core.UpdateInstancePoolDetails{
+			Size: common.Int(newSize),
+		},
+	}
+
+	err := c.retry(ctx, "UpdateInstancePool", func(ctx context.Context) error {
+		_, err := c.ComputeManagementClient.UpdateInstancePool(ctx, updateReq)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update instance pool: %w", err)
+	}
+
+	return nil
+}
+
+// TerminateInstancePool terminates an instance pool and all its instances
+func (c *OCIClient) TerminateInstancePool(ctx context.Context, instancePoolID string) error {
+	terminateReq := core.TerminateInstancePoolRequest{
+		InstancePoolId: common.String(instancePoolID),
+	}
+
+	err := c.retry(ctx, "TerminateInstancePool", func(ctx context.Context) error {
+		_, err := c.ComputeManagementClient.TerminateInstancePool(ctx, terminateReq)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to terminate instance pool: %w", err)
+	}
+
+	return nil
+}
+
+// GetInstancePool retrieves details about an instance pool
+func (c *OCIClient) GetInstancePool(ctx context.Context, instancePoolID string) (*core.InstancePool, error) {
+	getReq := core.GetInstancePoolRequest{
+		InstancePoolId: common.String(instancePoolID),
+	}
+
+	var resp core.GetInstancePoolResponse
+	err := c.retry(ctx, "GetInstancePool", func(ctx context.Context) error {
+		var err error
+		resp, err = c.ComputeManagementClient.GetInstancePool(ctx, getReq)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instance pool: %w", err)
+	}
+
+	return &resp.InstancePool, nil
+}
+
+// ListInstancePools lists every instance pool in a compartment, including
+// terminated ones
+func (c *OCIClient) ListInstancePools(ctx context.Context, compartmentID string) ([]core.InstancePoolSummary, error) {
+	listReq := core.ListInstancePoolsRequest{
+		CompartmentId: common.String(compartmentID),
+	}
+
+	var pools []core.InstancePoolSummary
+	for {
+		var resp core.ListInstancePoolsResponse
+		err := c.retry(ctx, "ListInstancePools", func(ctx context.Context) error {
+			var err error
+			resp, err = c.ComputeManagementClient.ListInstancePools(ctx, listReq)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instance pools: %w", err)
+		}
+		pools = append(pools, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return pools, nil
+		}
+		listReq.Page = resp.OpcNextPage
+	}
+}
+
+// ListInstancePoolInstances lists all instances in an instance pool
+func (c *OCIClient) ListInstancePoolInstances(ctx context.Context, compartmentID, instancePoolID string) ([]core.InstanceSummary, error) {
+	listReq := core.ListInstancePoolInstancesRequest{
+		CompartmentId:  common.String(compartmentID),
+		InstancePoolId: common.String(instancePoolID),
+	}
+
+	var instances []core.InstanceSummary
+	for {
+		var resp core.ListInstancePoolInstancesResponse
+		err := c.retry(ctx, "ListInstancePoolInstances", func(ctx context.Context) error {
+			var err error
+			resp, err = c.ComputeManagementClient.ListInstancePoolInstances(ctx, listReq)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instance pool instances: %w", err)
+		}
+		instances = append(instances, resp.Items...)
+		if resp.OpcNextPage == nil {
+			return instances, nil
+		}
+		listReq.Page = resp.OpcNextPage
+	}
+}
+
+// DetachInstance detaches an instance from a pool. With decrement the pool
+// shrinks by one; otherwise it launches a replacement. With terminate the
+// service terminates the detached instance; otherwise it keeps running on its own.
+func (c *OCIClient) DetachInstance(ctx context.Context, instancePoolID, instanceID string, decrement, terminate bool) error {
+	detachReq := core.DetachInstancePoolInstanceRequest{
+		InstancePoolId: common.String(instancePoolID),
+		DetachInstancePoolInstanceDetails: core.DetachInstancePoolInstanceDetails{
+			InstanceId:      common.String(instanceID),
+			IsDecrementSize: common.Bool(decrement),
+			IsAutoTerminate: common.Bool(terminate),
+		},
+		OpcRetryToken: common.String(common.RetryToken()),
+	}
+
+	err := c.retry(ctx, "DetachInstancePoolInstance", func(ctx context.Context) error {
+		_, err := c.ComputeManagementClient.DetachInstancePoolInstance(ctx, detachReq)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to detach instance: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+// fakeClient returns a client whose API calls all go to b
+func fakeClient(b *ocifake.Backend) *OCIClient {
+	return &OCIClient{
+		ComputeClient:           b,
+		ComputeManagementClient: b,
+		BlockstorageClient:      b,
+		IdentityClient:          b,
+		Config:                  &Config{Retry: testRetry},
+	}
+}
+
+// testPoolConfig returns a config for a pool of size instances in AD-1 and AD-2
+func testPoolConfig(size int) *Config {
+	return &Config{
+		CompartmentID: "ocid1.compartment.test",
+		InstancePool: InstancePoolConfig{
+			Size: size,
+			InstanceConfiguration: InstanceConfigurationSpec{
+				Shape:    "VM.Standard2.1",
+				ImageID:  "ocid1.image.test",
+				SubnetID: "ocid1.subnet.test",
+			},
+			Placement: []PlacementConfig{{AvailabilityDomain: "AD-1"}, {AvailabilityDomain: "AD-2"}},
+		},
+		Retry: testRetry,
+	}
+}
+
+func TestCreateInstancePoolFallsBackAndDeletesUnusedConfiguration(t *testing.T) {
+	b := ocifake.New()
+	b.InjectError(ocifake.OpCreateInstancePool, ocifake.LimitExceeded(), 1)
+	config := testPoolConfig(2)
+	config.InstancePool.InstanceConfiguration.FallbackShapes = []ShapeChoice{{Shape: "VM.Standard2.2"}}
+
+	pool, shape, err := fakeClient(b).CreateInstancePool(context.Background(), config, "run-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shape != "VM.Standard2.2" || derefString(pool.DisplayName) != "instance-pool-run-a" {
+		t.Errorf("created %q with %s, want instance-pool-run-a with the fallback shape", derefString(pool.DisplayName), shape)
+	}
+	configs := b.InstanceConfigurations()
+	if len(configs) != 1 || *configs[0].Id != *pool.InstanceConfigurationId {
+		t.Errorf("%d instance configurations left, want only the pool's", len(configs))
+	}
+}
+
+func TestCreateInstancePoolKeepsConfigurationOnOtherErrors(t *testing.T) {
+	b := ocifake.New()
+	b.InjectError(ocifake.OpCreateInstancePool, ocifake.NotFound("ocid1.subnet.test"), 1)
+	config := testPoolConfig(2)
+	config.InstancePool.InstanceConfiguration.FallbackShapes = []ShapeChoice{{Shape: "VM.Standard2.2"}}
+
+	if _, _, err := fakeClient(b).CreateInstancePool(context.Background(), config, "run-a"); err == nil {
+		t.Fatal("pool created despite the error")
+	}
+	if n := b.Calls(ocifake.OpDeleteInstanceConfiguration); n != 0 {
+		t.Errorf("made %d DeleteInstanceConfiguration calls, want none", n)
+	}
+}
+
+func TestCreateInstancePoolAgainWithTheSameRunID(t *testing.T) {
+	b := ocifake.New()
+	b.InjectLostResponse(ocifake.OpCreateInstancePool, ocifake.InternalError(), 1)
+	config := testPoolConfig(2)
+	config.Retry = RetryPolicy{MaxAttempts: 1}
+	client := fakeClient(b)
+	client.Config = config
+
+	if _, _, err := client.CreateInstancePool(context.Background(), config, "run-a"); err == nil {
+		t.Fatal("lost response was not reported")
+	}
+	pool, _, err := client.CreateInstancePool(context.Background(), config, "run-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pools := b.Pools(); len(pools) != 1 || *pools[0].Id != *pool.Id {
+		t.Errorf("%d pools, want the one the first attempt made", len(pools))
+	}
+	if n := len(b.InstanceConfigurations()); n != 1 {
+		t.Errorf("%d instance configurations, want 1", n)
+	}
+	if pool.LifecycleState != core.InstancePoolLifecycleStateRunning || *pool.Size != 2 {
+		t.Errorf("pool is %s with %d instances, want RUNNING with 2", pool.LifecycleState, *pool.Size)
+	}
+}
+
+// createFakePool creates a pool of size instances in b and returns its ID
+func createFakePool(t *testing.T, b *ocifake.Backend, size int) string {
+	t.Helper()
+	pool, _, err := fakeClient(b).CreateInstancePool(context.Background(), testPoolConfig(size), "run-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *pool.Id
+}
@@ -0,0 +1,456 @@
+// Package ocifake provides an in-memory stand-in for the parts of the OCI
+// Compute, ComputeManagement, Block Storage and Identity APIs used by
+// oci-insta-scale. Backend implements the same method signatures as
+// core.ComputeClient, core.ComputeManagementClient, core.BlockstorageClient
+// and identity.IdentityClient, so it can be dropped in wherever the tools
+// accept their API interfaces.
+package ocifake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// Operation names accepted by InjectError and Calls
+const (
+	OpLaunchInstance              = "LaunchInstance"
+	OpGetInstance                 = "GetInstance"
+	OpListInstances               = "ListInstances"
+	OpTerminateInstance           = "TerminateInstance"
+	OpCreateInstanceConfiguration = "CreateInstanceConfiguration"
+	OpDeleteInstanceConfiguration = "DeleteInstanceConfiguration"
+	OpCreateInstancePool          = "CreateInstancePool"
+	OpUpdateInstancePool          = "UpdateInstancePool"
+	OpGetInstancePool             = "GetInstancePool"
+	OpTerminateInstancePool       = "TerminateInstancePool"
+	OpListInstancePoolInstances   = "ListInstancePoolInstances"
+	OpDetachInstancePoolInstance  = "DetachInstancePoolInstance"
+	OpListCompartments            = "ListCompartments"
+	OpListInstanceConfigurations  = "ListInstanceConfigurations"
+	OpListInstancePools           = "ListInstancePools"
+	OpListBootVolumes             = "ListBootVolumes"
+	OpDeleteBootVolume            = "DeleteBootVolume"
+	OpListBootVolumeAttachments   = "ListBootVolumeAttachments"
+	OpListVolumes                 = "ListVolumes"
+	OpDeleteVolume                = "DeleteVolume"
+	OpListVolumeAttachments       = "ListVolumeAttachments"
+)
+
+// Timeline controls how long simulated resources stay in each transitional
+// lifecycle state. Zero durations make transitions immediate.
+type Timeline struct {
+	Provisioning time.Duration // PROVISIONING -> RUNNING
+	Terminating  time.Duration // TERMINATING -> TERMINATED
+}
+
+// Option configures a Backend
+type Option func(*Backend)
+
+// WithClock replaces the wall clock used to drive lifecycle transitions
+func WithClock(now func() time.Time) Option {
+	return func(b *Backend) { b.now = now }
+}
+
+// WithTimeline sets the lifecycle durations for new and existing resources
+func WithTimeline(t Timeline) Option {
+	return func(b *Backend) { b.timeline = t }
+}
+
+// WithLatency delays every API call by d
+func WithLatency(d time.Duration) Option {
+	return func(b *Backend) { b.latency = d }
+}
+
+// WithRegion sets the region reported on simulated resources
+func WithRegion(region string) Option {
+	return func(b *Backend) { b.region = region }
+}
+
+// Backend is an in-memory OCI compute service. It is safe for concurrent use.
+type Backend struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	timeline Timeline
+	latency  time.Duration
+	region   string
+	seq      int
+
+	faults    []*fault
+	capacity  []Capacity
+	calls     map[string]int
+	tokens    map[string]string // op and retry token -> ID of the resource created
+	instances map[string]*instance
+	configs   map[string]*core.InstanceConfiguration
+	pools     map[string]*pool
+
+	compartments map[string]Compartment
+	bootVolumes  map[string]*bootVolume
+	volumes      map[string]*blockVolume
+}
+
+// New creates an empty Backend
+func New(opts ...Option) *Backend {
+	b := &Backend{
+		now:       time.Now,
+		region:    "fake-region-1",
+		calls:     make(map[string]int),
+		tokens:    make(map[string]string),
+		instances: make(map[string]*instance),
+		configs:   make(map[string]*core.InstanceConfiguration),
+		pools:     make(map[string]*pool),
+
+		compartments: make(map[string]Compartment),
+		bootVolumes:  make(map[string]*bootVolume),
+		volumes:      make(map[string]*blockVolume),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// SetTimeline changes the lifecycle durations at runtime
+func (b *Backend) SetTimeline(t Timeline) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.timeline = t
+}
+
+// SetLatency changes the per-call delay at runtime
+func (b *Backend) SetLatency(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latency = d
+}
+
+// Calls returns how many times op has been invoked, including failed calls
+func (b *Backend) Calls(op string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls[op]
+}
+
+// instance is the simulated state of a single compute instance
+type instance struct {
+	details      core.Instance
+	launchedAt   time.Time
+	terminatedAt time.Time
+	forced       core.InstanceLifecycleStateEnum
+	bootVolumeID string
+}
+
+// state derives the lifecycle state of inst at now from the timeline
+func (b *Backend) state(inst *instance, now time.Time) core.InstanceLifecycleStateEnum {
+	if inst.forced != "" {
+		return inst.forced
+	}
+	if !inst.terminatedAt.IsZero() {
+		if now.Sub(inst.terminatedAt) >= b.timeline.Terminating {
+			return core.InstanceLifecycleStateTerminated
+		}
+		return core.InstanceLifecycleStateTerminating
+	}
+	if now.Sub(inst.launchedAt) >= b.timeline.Provisioning {
+		return core.InstanceLifecycleStateRunning
+	}
+	return core.InstanceLifecycleStateProvisioning
+}
+
+// snapshot returns a copy of inst with its current lifecycle state filled in
+func (b *Backend) snapshot(inst *instance) core.Instance {
+	out := inst.details
+	out.LifecycleState = b.state(inst, b.now())
+	return out
+}
+
+// Instances returns a snapshot of every instance the backend knows about,
+// ordered by creation
+func (b *Backend) Instances() []core.Instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]core.Instance, 0, len(b.instances))
+	for _, inst := range b.instances {
+		out = append(out, b.snapshot(inst))
+	}
+	sort.Slice(out, func(i, j int) bool { return *out[i].Id < *out[j].Id })
+	return out
+}
+
+// SetInstanceState pins an instance to state regardless of the timeline, for
+// example to simulate an instance that stops or fails during provisioning.
+// Passing an empty state returns the instance to timeline-driven transitions.
+func (b *Backend) SetInstanceState(instanceID string, state core.InstanceLifecycleStateEnum) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	inst, ok := b.instances[instanceID]
+	if !ok {
+		return NotFound(instanceID)
+	}
+	inst.forced = state
+	return nil
+}
+
+// begin applies latency, records the call and returns any injected fault
+func (b *Backend) begin(ctx context.Context, op string) error {
+	b.mu.Lock()
+	latency := b.latency
+	b.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls[op]++
+	return b.takeFault(op)
+}
+
+// nextID returns a new fake OCID for the given resource type. Callers must hold b.mu.
+func (b *Backend) nextID(resource string) string {
+	b.seq++
+	return fmt.Sprintf("ocid1.%s.oc1.fake.%06d", resource, b.seq)
+}
+
+// retried returns the ID of the resource op already created with token, so a
+// retried create returns it instead of making another, as the service does.
+// Callers must hold b.mu.
+func (b *Backend) retried(op string, token *string) (string, bool) {
+	if derefString(token) == "" {
+		return "", false
+	}
+	id, ok := b.tokens[op+"/"+*token]
+	return id, ok
+}
+
+// remember records that op created id with token. Callers must hold b.mu.
+func (b *Backend) remember(op string, token *string, id string) {
+	if derefString(token) != "" {
+		b.tokens[op+"/"+*token] = id
+	}
+}
+
+// launch creates a new instance record. Callers must hold b.mu.
+func (b *Backend) launch(details core.Instance) *instance {
+	now := b.now()
+	details.Id = common.String(b.nextID("instance"))
+	details.Region = common.String(b.region)
+	details.TimeCreated = &common.SDKTime{Time: now}
+	inst := &instance{details: details, launchedAt: now}
+	b.instances[*details.Id] = inst
+	b.createBootVolume(inst)
+	return inst
+}
+
+// terminate marks inst as terminating and releases its boot volume, deleting
+// it unless preserveBootVolume is set, and detaches its block volumes. Callers
+// must hold b.mu.
+func (b *Backend) terminate(inst *instance, preserveBootVolume bool) {
+	if inst.terminatedAt.IsZero() {
+		inst.terminatedAt = b.now()
+		b.releaseBootVolume(inst, preserveBootVolume)
+		b.detachVolumes(inst)
+	}
+	if inst.forced != core.InstanceLifecycleStateTerminated {
+		inst.forced = ""
+	}
+}
+
+// LaunchInstance simulates core.ComputeClient.LaunchInstance
+func (b *Backend) LaunchInstance(ctx context.Context, request core.LaunchInstanceRequest) (core.LaunchInstanceResponse, error) {
+	if err := b.begin(ctx, OpLaunchInstance); err != nil {
+		return core.LaunchInstanceResponse{}, err
+	}
+
+	d := request.LaunchInstanceDetails
+	required := []struct {
+		name  string
+		value *string
+	}{
+		{"compartmentId", d.CompartmentId},
+		{"availabilityDomain", d.AvailabilityDomain},
+		{"shape", d.Shape},
+	}
+	for _, r := range required {
+		if derefString(r.value) == "" {
+			return core.LaunchInstanceResponse{}, ServiceError{StatusCode: 400, Code: "MissingParameter", Message: fmt.Sprintf("Missing %s", r.name)}
+		}
+	}
+
+	shapeConfig, err := launchShapeConfig(*d.Shape, d.ShapeConfig)
+	if err != nil {
+		return core.LaunchInstanceResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if id, ok := b.retried(OpLaunchInstance, request.OpcRetryToken); ok {
+		return core.LaunchInstanceResponse{Instance: b.snapshot(b.instances[id])}, nil
+	}
+
+	details := core.Instance{
+		ShapeConfig:        shapeConfig,
+		AvailabilityDomain: d.AvailabilityDomain,
+		CompartmentId:      d.CompartmentId,
+		DisplayName:        d.DisplayName,
+		Shape:              d.Shape,
+		ImageId:            d.ImageId,
+		FaultDomain:        d.FaultDomain,
+		FreeformTags:       d.FreeformTags,
+		DefinedTags:        d.DefinedTags,
+		Metadata:           d.Metadata,
+	}
+	fd, err := b.placeFaultDomain(*d.AvailabilityDomain, derefString(d.FaultDomain), *d.Shape)
+	if err != nil {
+		return core.LaunchInstanceResponse{}, err
+	}
+	details.FaultDomain = common.String(fd)
+	inst := b.launch(details)
+	b.remember(OpLaunchInstance, request.OpcRetryToken, *inst.details.Id)
+	if err := b.takeLostResponse(OpLaunchInstance); err != nil {
+		return core.LaunchInstanceResponse{}, err
+	}
+
+	return core.LaunchInstanceResponse{Instance: b.snapshot(inst)}, nil
+}
+
+// launchShapeConfig checks a launch's shape config the way the service does:
+// flexible shapes need OCPUs, fixed shapes must not send a config at all. It
+// returns the config to report on the instance.
+func launchShapeConfig(shape string, c *core.LaunchInstanceShapeConfigDetails) (*core.InstanceShapeConfig, error) {
+	if !strings.HasSuffix(shape, ".Flex") {
+		if c != nil {
+			return nil, ServiceError{StatusCode: 400, Code: "InvalidParameter", Message: fmt.Sprintf("Shape %s does not support shapeConfig", shape)}
+		}
+		return nil, nil
+	}
+	if c == nil || c.Ocpus == nil {
+		return nil, ServiceError{StatusCode: 400, Code: "InvalidParameter", Message: fmt.Sprintf("shapeConfig.ocpus is required for flexible shape %s", shape)}
+	}
+	return &core.InstanceShapeConfig{
+		Ocpus:                   c.Ocpus,
+		MemoryInGBs:             c.MemoryInGBs,
+		BaselineOcpuUtilization: core.InstanceShapeConfigBaselineOcpuUtilizationEnum(c.BaselineOcpuUtilization),
+		LocalDisks:              c.Nvmes,
+	}, nil
+}
+
+// GetInstance simulates core.ComputeClient.GetInstance
+func (b *Backend) GetInstance(ctx context.Context, request core.GetInstanceRequest) (core.GetInstanceResponse, error) {
+	if err := b.begin(ctx, OpGetInstance); err != nil {
+		return core.GetInstanceResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	inst, ok := b.instances[derefString(request.InstanceId)]
+	if !ok {
+		return core.GetInstanceResponse{}, NotFound(derefString(request.InstanceId))
+	}
+	return core.GetInstanceResponse{Instance: b.snapshot(inst)}, nil
+}
+
+// ListInstances simulates core.ComputeClient.ListInstances, filtering on
+// compartment, availability domain, display name and lifecycle state. Results
+// are ordered by creation time and paged when the request sets a limit.
+func (b *Backend) ListInstances(ctx context.Context, request core.ListInstancesRequest) (core.ListInstancesResponse, error) {
+	if err := b.begin(ctx, OpListInstances); err != nil {
+		return core.ListInstancesResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	items := []core.Instance{} // the SDK cannot unmarshal a null list
+	for _, inst := range b.instances {
+		d := inst.details
+		switch {
+		case derefString(request.CompartmentId) != derefString(d.CompartmentId):
+		case request.AvailabilityDomain != nil && *request.AvailabilityDomain != derefString(d.AvailabilityDomain):
+		case request.DisplayName != nil && *request.DisplayName != derefString(d.DisplayName):
+		case request.LifecycleState != "" && string(request.LifecycleState) != string(b.state(inst, now)):
+		default:
+			items = append(items, b.snapshot(inst))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].TimeCreated.Equal(items[j].TimeCreated.Time) {
+			return items[i].TimeCreated.Before(items[j].TimeCreated.Time)
+		}
+		return *items[i].Id < *items[j].Id
+	})
+
+	var resp core.ListInstancesResponse
+	resp.Items, resp.OpcNextPage = page(items, request.Page, request.Limit, 0)
+	return resp, nil
+}
+
+// page returns the page of items that starts at the offset in token, holding
+// at most limit items, or defaultLimit if limit is not set (0 for no limit),
+// and the token of the next page if there is one
+func page[T any](items []T, token *string, limit *int, defaultLimit int) ([]T, *string) {
+	start := 0
+	if token != nil {
+		fmt.Sscan(*token, &start)
+	}
+	if start > len(items) {
+		start = len(items)
+	}
+	items = items[start:]
+	n := defaultLimit
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	if n > 0 && len(items) > n {
+		return items[:n], common.String(fmt.Sprint(start + n))
+	}
+	return items, nil
+}
+
+// TerminateInstance simulates core.ComputeClient.TerminateInstance, deleting
+// the boot volume unless PreserveBootVolume is set. Terminating an instance
+// that is already terminating or terminated succeeds.
+func (b *Backend) TerminateInstance(ctx context.Context, request core.TerminateInstanceRequest) (core.TerminateInstanceResponse, error) {
+	if err := b.begin(ctx, OpTerminateInstance); err != nil {
+		return core.TerminateInstanceResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	inst, ok := b.instances[derefString(request.InstanceId)]
+	if !ok {
+		return core.TerminateInstanceResponse{}, NotFound(derefString(request.InstanceId))
+	}
+	b.terminate(inst, request.PreserveBootVolume != nil && *request.PreserveBootVolume)
+	return core.TerminateInstanceResponse{}, nil
+}
+
+// displayState converts a lifecycle state to the capitalised form used by
+// ListInstancePoolInstances, e.g. RUNNING -> Running
+func displayState(state core.InstanceLifecycleStateEnum) string {
+	s := strings.ToLower(string(state))
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
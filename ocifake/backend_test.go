@@ -0,0 +1,207 @@
+package ocifake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+func launchRequest(name string) core.LaunchInstanceRequest {
+	return core.LaunchInstanceRequest{LaunchInstanceDetails: core.LaunchInstanceDetails{
+		CompartmentId:      common.String("ocid1.compartment.oc1..test"),
+		AvailabilityDomain: common.String("AD-1"),
+		Shape:              common.String("VM.Standard2.1"),
+		DisplayName:        common.String(name),
+	}}
+}
+
+func getState(t *testing.T, b *Backend, id string) core.InstanceLifecycleStateEnum {
+	t.Helper()
+	resp, err := b.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: common.String(id)})
+	if err != nil {
+		t.Fatalf("GetInstance: %v", err)
+	}
+	return resp.LifecycleState
+}
+
+func TestInstanceLifecycleFollowsTimeline(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(WithClock(clock.Now), WithTimeline(Timeline{Provisioning: time.Minute, Terminating: 30 * time.Second}))
+	ctx := context.Background()
+
+	resp, err := b.LaunchInstance(ctx, launchRequest("web-1"))
+	if err != nil {
+		t.Fatalf("LaunchInstance: %v", err)
+	}
+	id := *resp.Id
+	if resp.LifecycleState != core.InstanceLifecycleStateProvisioning {
+		t.Fatalf("state after launch = %s, want PROVISIONING", resp.LifecycleState)
+	}
+
+	clock.Advance(59 * time.Second)
+	if got := getState(t, b, id); got != core.InstanceLifecycleStateProvisioning {
+		t.Fatalf("state before the timeline = %s, want PROVISIONING", got)
+	}
+	clock.Advance(time.Second)
+	if got := getState(t, b, id); got != core.InstanceLifecycleStateRunning {
+		t.Fatalf("state after the timeline = %s, want RUNNING", got)
+	}
+
+	if _, err := b.TerminateInstance(ctx, core.TerminateInstanceRequest{InstanceId: common.String(id)}); err != nil {
+		t.Fatalf("TerminateInstance: %v", err)
+	}
+	if got := getState(t, b, id); got != core.InstanceLifecycleStateTerminating {
+		t.Fatalf("state after terminate = %s, want TERMINATING", got)
+	}
+	clock.Advance(30 * time.Second)
+	if got := getState(t, b, id); got != core.InstanceLifecycleStateTerminated {
+		t.Fatalf("state after terminating = %s, want TERMINATED", got)
+	}
+}
+
+func TestSetInstanceStateOverridesTimeline(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	resp, err := b.LaunchInstance(ctx, launchRequest("web-1"))
+	if err != nil {
+		t.Fatalf("LaunchInstance: %v", err)
+	}
+	if err := b.SetInstanceState(*resp.Id, core.InstanceLifecycleStateStopped); err != nil {
+		t.Fatalf("SetInstanceState: %v", err)
+	}
+	if got := getState(t, b, *resp.Id); got != core.InstanceLifecycleStateStopped {
+		t.Fatalf("state = %s, want STOPPED", got)
+	}
+	if err := b.SetInstanceState("ocid1.instance.missing", core.InstanceLifecycleStateStopped); err == nil {
+		t.Fatal("SetInstanceState on a missing instance succeeded")
+	}
+}
+
+func TestInjectErrorFailsTheNextCalls(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	b.InjectError(OpLaunchInstance, TooManyRequests(), 2)
+
+	for i := 0; i < 2; i++ {
+		_, err := b.LaunchInstance(ctx, launchRequest("web-1"))
+		var serr ServiceError
+		if !errors.As(err, &serr) || serr.StatusCode != 429 {
+			t.Fatalf("call %d: err = %v, want a 429", i+1, err)
+		}
+	}
+	if _, err := b.LaunchInstance(ctx, launchRequest("web-1")); err != nil {
+		t.Fatalf("third call: %v", err)
+	}
+	if got := b.Calls(OpLaunchInstance); got != 3 {
+		t.Errorf("Calls = %d, want 3", got)
+	}
+	if got := len(b.Instances()); got != 1 {
+		t.Errorf("%d instances, want 1 (failed calls must not launch)", got)
+	}
+}
+
+func TestInjectErrorWithoutLimitFailsUntilCleared(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	resp, err := b.LaunchInstance(ctx, launchRequest("web-1"))
+	if err != nil {
+		t.Fatalf("LaunchInstance: %v", err)
+	}
+	b.InjectError(OpGetInstance, InternalError(), 0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := b.GetInstance(ctx, core.GetInstanceRequest{InstanceId: resp.Id}); err == nil {
+			t.Fatalf("call %d succeeded", i+1)
+		}
+	}
+	b.ClearFaults()
+	if _, err := b.GetInstance(ctx, core.GetInstanceRequest{InstanceId: resp.Id}); err != nil {
+		t.Fatalf("after ClearFaults: %v", err)
+	}
+}
+
+func TestRetryTokenReplaysLostLaunch(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	b.InjectLostResponse(OpLaunchInstance, InternalError(), 1)
+
+	req := launchRequest("web-1")
+	req.OpcRetryToken = common.String("token-1")
+	if _, err := b.LaunchInstance(ctx, req); err == nil {
+		t.Fatal("lost response was not reported")
+	}
+	if got := len(b.Instances()); got != 1 {
+		t.Fatalf("%d instances after a lost response, want 1", got)
+	}
+
+	resp, err := b.LaunchInstance(ctx, req)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got := len(b.Instances()); got != 1 {
+		t.Fatalf("%d instances after retrying with the same token, want 1", got)
+	}
+	if *resp.Id != *b.Instances()[0].Id {
+		t.Errorf("retry returned %s, want the instance already launched", *resp.Id)
+	}
+}
+
+func TestCapacityLimitsLaunches(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	b.SetCapacity(Capacity{AvailabilityDomain: "AD-1", Shape: "VM.Standard2.1", Limit: 1})
+
+	if _, err := b.LaunchInstance(ctx, launchRequest("web-1")); err != nil {
+		t.Fatalf("first launch: %v", err)
+	}
+	_, err := b.LaunchInstance(ctx, launchRequest("web-2"))
+	var serr ServiceError
+	if !errors.As(err, &serr) || serr.Message != OutOfCapacity().Message {
+		t.Fatalf("second launch: err = %v, want out of capacity", err)
+	}
+
+	other := launchRequest("web-3")
+	other.AvailabilityDomain = common.String("AD-2")
+	if _, err := b.LaunchInstance(ctx, other); err != nil {
+		t.Errorf("launch in another AD: %v", err)
+	}
+}
+
+func TestListInstancesPages(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		if _, err := b.LaunchInstance(ctx, launchRequest(name)); err != nil {
+			t.Fatalf("LaunchInstance: %v", err)
+		}
+	}
+
+	var names []string
+	var page *string
+	pages := 0
+	for {
+		resp, err := b.ListInstances(ctx, core.ListInstancesRequest{
+			CompartmentId: common.String("ocid1.compartment.oc1..test"),
+			Limit:         common.Int(2),
+			Page:          page,
+		})
+		if err != nil {
+			t.Fatalf("ListInstances: %v", err)
+		}
+		pages++
+		for _, inst := range resp.Items {
+			names = append(names, *inst.DisplayName)
+		}
+		if resp.OpcNextPage == nil {
+			break
+		}
+		page = resp.OpcNextPage
+	}
+	if pages != 3 || len(names) != 5 {
+		t.Errorf("listed %d instances over %d pages, want 5 over 3", len(names), pages)
+	}
+}
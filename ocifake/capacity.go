@@ -0,0 +1,94 @@
+package ocifake
+
+import (
+	"fmt"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// Capacity limits how many live (not yet TERMINATED) instances fit in a slice
+// of the fake region. Empty fields match anything, so
+// Capacity{AvailabilityDomain: "AD-1"} with a zero Limit leaves AD-1 with no
+// host capacity at all. LaunchInstance fails with OutOfCapacity when any
+// matching rule is full.
+type Capacity struct {
+	AvailabilityDomain string `json:"availabilityDomain,omitempty"`
+	FaultDomain        string `json:"faultDomain,omitempty"`
+	Shape              string `json:"shape,omitempty"`
+	Limit              int    `json:"limit"`
+}
+
+// matches reports whether inst falls inside the rule
+func (c Capacity) matches(ad, fd, shape string) bool {
+	return (c.AvailabilityDomain == "" || c.AvailabilityDomain == ad) &&
+		(c.FaultDomain == "" || c.FaultDomain == fd) &&
+		(c.Shape == "" || c.Shape == shape)
+}
+
+// SetCapacity adds a capacity rule, replacing any rule with the same
+// availability domain, fault domain and shape
+func (b *Backend) SetCapacity(c Capacity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, existing := range b.capacity {
+		if existing.AvailabilityDomain == c.AvailabilityDomain && existing.FaultDomain == c.FaultDomain && existing.Shape == c.Shape {
+			b.capacity[i] = c
+			return
+		}
+	}
+	b.capacity = append(b.capacity, c)
+}
+
+// ClearCapacity removes every capacity rule, making capacity unlimited again
+func (b *Backend) ClearCapacity() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.capacity = nil
+}
+
+// hasCapacity reports whether one more instance fits in the given placement.
+// Callers must hold b.mu.
+func (b *Backend) hasCapacity(ad, fd, shape string) bool {
+	if len(b.capacity) == 0 {
+		return true
+	}
+	now := b.now()
+	for _, c := range b.capacity {
+		if !c.matches(ad, fd, shape) {
+			continue
+		}
+		used := 0
+		for _, inst := range b.instances {
+			d := inst.details
+			if c.matches(derefString(d.AvailabilityDomain), derefString(d.FaultDomain), derefString(d.Shape)) &&
+				b.state(inst, now) != core.InstanceLifecycleStateTerminated {
+				used++
+			}
+		}
+		if used >= c.Limit {
+			return false
+		}
+	}
+	return true
+}
+
+// placeFaultDomain picks the fault domain for a launch. A requested fault
+// domain is used as-is if it has capacity; otherwise the three fault domains
+// are tried starting from a round-robin position, as the service does when
+// none is given. Callers must hold b.mu.
+func (b *Backend) placeFaultDomain(ad, requested, shape string) (string, error) {
+	if requested != "" {
+		if !b.hasCapacity(ad, requested, shape) {
+			return "", OutOfCapacity()
+		}
+		return requested, nil
+	}
+	start := len(b.instances)
+	for i := 0; i < 3; i++ {
+		fd := fmt.Sprintf("FAULT-DOMAIN-%d", (start+i)%3+1)
+		if b.hasCapacity(ad, fd, shape) {
+			return fd, nil
+		}
+	}
+	return "", OutOfCapacity()
+}
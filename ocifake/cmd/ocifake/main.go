@@ -0,0 +1,63 @@
+// Command ocifake serves an in-memory OCI Compute, ComputeManagement, Block
+// Storage and Identity API over HTTP so oci-insta-scale can be exercised end
+// to end without a tenancy. Point the tools at it with their -endpoint flag.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+func main() {
+	var (
+		listen       = flag.String("listen", "127.0.0.1:9090", "Address to listen on")
+		scenarioFile = flag.String("scenario", "", "JSON scenario with timeline, latency and faults (optional)")
+		provisioning = flag.Duration("provisioning", 0, "Time instances spend in PROVISIONING (overrides scenario)")
+		terminating  = flag.Duration("terminating", 0, "Time instances spend in TERMINATING (overrides scenario)")
+		latency      = flag.Duration("latency", 0, "Delay added to every API call (overrides scenario)")
+		quiet        = flag.Bool("quiet", false, "Do not log requests")
+	)
+	flag.Parse()
+
+	backend := ocifake.New()
+	scenario := &ocifake.Scenario{}
+	if *scenarioFile != "" {
+		sc, err := ocifake.LoadScenario(*scenarioFile)
+		if err != nil {
+			log.Fatalf("Failed to load scenario: %v", err)
+		}
+		scenario = sc
+	}
+	if *provisioning > 0 {
+		scenario.Timeline.Provisioning = ocifake.Duration(*provisioning)
+	}
+	if *terminating > 0 {
+		scenario.Timeline.Terminating = ocifake.Duration(*terminating)
+	}
+	if *latency > 0 {
+		scenario.Latency = ocifake.Duration(*latency)
+	}
+	if err := scenario.Apply(backend); err != nil {
+		log.Fatalf("Failed to apply scenario: %v", err)
+	}
+	go func() {
+		if err := scenario.Run(context.Background(), backend); err != nil {
+			log.Fatalf("Scenario step failed: %v", err)
+		}
+	}()
+
+	server := ocifake.NewServer(backend)
+	if !*quiet {
+		server.Logger = log.New(os.Stderr, "ocifake: ", log.LstdFlags)
+	}
+
+	log.Printf("Fake OCI API listening on http://%s", *listen)
+	httpServer := &http.Server{Addr: *listen, Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	log.Fatal(httpServer.ListenAndServe())
+}
@@ -0,0 +1,542 @@
+#!/bin/bash
+#
+# End-to-end test for oci-insta-scale against the ocifake server.
+# Builds the server and the CLI, points it at the server with -endpoint, and
+# checks launch, resume, terminate by file and by selectors, instance
+# configuration, boot and block volume cleanup, sweep, reap and every pool action, including
+# a launch that hits injected throttling, capacity and limit errors and one
+# held back by the client-side rate limiter.
+#
+# Requires go, openssl, curl and jq. Run from anywhere:
+#   ./ocifake/e2e.sh
+
+set -euo pipefail
+
+ROOT="$(cd "$(dirname "$0")/.." && pwd)"
+PORT="${OCIFAKE_PORT:-9090}"
+ENDPOINT="http://127.0.0.1:$PORT"
+WORK="$(mktemp -d)"
+SERVER_PID=""
+
+cleanup() {
+    if [ -n "$SERVER_PID" ]; then
+        kill "$SERVER_PID" 2>/dev/null || true
+    fi
+    rm -rf "$WORK"
+}
+trap cleanup EXIT
+
+fail() {
+    echo "FAIL: $*"
+    exit 1
+}
+
+# expect <pattern> <file>: fail unless the output file contains pattern
+expect() {
+    grep -q -- "$1" "$2" || { cat "$2"; fail "expected '$1' in output"; }
+}
+
+inject() {
+    curl -sf -X POST "$ENDPOINT/_fake/faults" -d "$1" >/dev/null
+}
+
+echo "Building binaries into $WORK"
+(cd "$ROOT/ocifake" && go build -o "$WORK/ocifake" ./cmd/ocifake)
+(cd "$ROOT" && go build -o "$WORK/oci-insta-scale" .)
+
+# The SDK signs every request, so the tool needs a key even though the fake
+# never checks signatures. Without API key settings in -config it falls back
+# to OCI_CONFIG_FILE when there is no ~/.oci/config.
+openssl genrsa -out "$WORK/key.pem" 2048 2>/dev/null
+cat > "$WORK/oci-config" <<EOF
+[DEFAULT]
+user=ocid1.user.oc1..e2e
+fingerprint=00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff
+key_file=$WORK/key.pem
+tenancy=ocid1.tenancy.oc1..e2e
+region=us-ashburn-1
+EOF
+export OCI_CONFIG_FILE="$WORK/oci-config"
+
+cat > "$WORK/pool.yaml" <<EOF
+tenancy_ocid: "ocid1.tenancy.oc1..e2e"
+user_ocid: "ocid1.user.oc1..e2e"
+fingerprint: "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff"
+private_key_path: "$WORK/key.pem"
+region: "us-ashburn-1"
+compartment_id: "ocid1.compartment.oc1..e2e"
+instance_pool:
+  display_name: "e2e-pool"
+  instance_configuration:
+    shape: "VM.Standard.E4.Flex"
+    shape_config:
+      ocpus: 1
+      memory_in_gbs: 8
+    fallback_shapes:
+      - shape: "VM.Standard.E5.Flex"
+        shape_config:
+          ocpus: 1
+          memory_in_gbs: 8
+    image_id: "ocid1.image.oc1..e2e"
+    subnet_id: "ocid1.subnet.oc1..e2e"
+    ssh_authorized_keys: "ssh-ed25519 AAAAe2e"
+    freeform_tags:
+      team: "e2e"
+  placement:
+    - availability_domain: "AD-1"
+    - availability_domain: "AD-2"
+EOF
+
+echo "Starting ocifake on $ENDPOINT"
+"$WORK/ocifake" -listen "127.0.0.1:$PORT" -quiet &
+SERVER_PID=$!
+for _ in $(seq 1 50); do
+    curl -sf "$ENDPOINT/_fake/state" >/dev/null && break
+    sleep 0.1
+done
+
+LAUNCH_FLAGS=(-name e2e -image ocid1.image.oc1..e2e -subnet ocid1.subnet.oc1..e2e
+    -compartment ocid1.compartment.oc1..e2e -ad AD-1 -endpoint "$ENDPOINT" -retry-base-delay 10ms)
+
+echo "--- subcommand usage and exit codes"
+"$WORK/oci-insta-scale" launch -h > "$WORK/out" 2>&1 || fail "expected launch -h to exit 0"
+expect "Usage: oci-insta-scale launch \[flags\]" "$WORK/out"
+STATUS=0; "$WORK/oci-insta-scale" pool > "$WORK/out" 2>&1 || STATUS=$?
+[ "$STATUS" = "2" ] || fail "expected exit 2 from pool with no action, got $STATUS"
+expect "detach" "$WORK/out"
+STATUS=0; "$WORK/oci-insta-scale" launch -instances 1 > "$WORK/out" 2>&1 || STATUS=$?
+[ "$STATUS" = "2" ] || fail "expected exit 2 when required flags are missing, got $STATUS"
+
+echo "--- launch 3 instances"
+(cd "$WORK" && ./oci-insta-scale launch -instances 3 "${LAUNCH_FLAGS[@]}" -output instances.json) > "$WORK/out" 2>&1
+expect "Summary: 3/3 instances created successfully" "$WORK/out"
+expect "Run manifest written to instances.json" "$WORK/out"
+[ "$(jq -r .version "$WORK/instances.json")" = "1" ] || fail "expected a version 1 run manifest"
+[ "$(jq '[.instances[] | select(.state == "RUNNING" and .id)] | length' "$WORK/instances.json")" -eq 3 ] \
+    || fail "expected 3 running instances in instances.json"
+
+echo "--- terminate them"
+(cd "$WORK" && ./oci-insta-scale terminate -file instances.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+expect "Summary: 3/3 terminations accepted" "$WORK/out"
+
+echo "--- launch in waves with bounded concurrency"
+(cd "$WORK" && ./oci-insta-scale launch -instances 5 "${LAUNCH_FLAGS[@]}" -parallel 2 -wave-size 2 -output waves.json) > "$WORK/out" 2>&1
+expect "Launching in 3 waves of up to 2" "$WORK/out"
+expect "Wave 3/3: 1/1 running, 0 failed" "$WORK/out"
+expect "Summary: 5/5 instances created successfully" "$WORK/out"
+(cd "$WORK" && ./oci-insta-scale terminate -file waves.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+expect "Summary: 5/5 terminations accepted" "$WORK/out"
+
+echo "--- launch through retryable throttling and capacity errors"
+inject '{"op": "LaunchInstance", "error": "TooManyRequests", "times": 2}'
+inject '{"op": "LaunchInstance", "error": "OutOfCapacity", "times": 1}'
+(cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -output retried.json) > "$WORK/out" 2>&1
+expect "Summary: 2/2 instances created successfully" "$WORK/out"
+expect "Launches that needed retries" "$WORK/out"
+(cd "$WORK" && ./oci-insta-scale terminate -file retried.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- launch under a tight write rate limit"
+START=$(date +%s%N)
+(cd "$WORK" && ./oci-insta-scale launch -instances 5 "${LAUNCH_FLAGS[@]}" -write-rps 4 -write-burst 1 -output limited.json) > "$WORK/out" 2>&1
+ELAPSED_MS=$(( ($(date +%s%N) - START) / 1000000 ))
+expect "Summary: 5/5 instances created successfully" "$WORK/out"
+# 5 launches at 4/s with no burst need at least a second; terminates share the same limit
+[ "$ELAPSED_MS" -ge 1000 ] || fail "expected rate-limited launches to take at least 1s, took ${ELAPSED_MS}ms"
+(cd "$WORK" && ./oci-insta-scale terminate -file limited.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT" -write-rps 4 -write-burst 1) > "$WORK/out" 2>&1
+expect "Summary: 5/5 terminations accepted" "$WORK/out"
+
+echo "--- launch falls back to the next AD and fault domain when one is full"
+curl -sf -X PUT "$ENDPOINT/_fake/capacity" -d '{"availabilityDomain": "AD-1", "limit": 0}' >/dev/null
+curl -sf -X PUT "$ENDPOINT/_fake/capacity" -d '{"availabilityDomain": "AD-2", "faultDomain": "FAULT-DOMAIN-1", "limit": 1}' >/dev/null
+(cd "$WORK" && ./oci-insta-scale launch -instances 3 "${LAUNCH_FLAGS[@]}" -ad AD-1,AD-2 -fd FAULT-DOMAIN-1,FAULT-DOMAIN-2 -parallel 1 -output fallback.json) > "$WORK/out" 2>&1
+expect "Summary: 3/3 instances created successfully" "$WORK/out"
+expect "Launches that fell back to another shape or placement: 3" "$WORK/out"
+expect "AD-2/FAULT-DOMAIN-1: 1" "$WORK/out"
+expect "AD-2/FAULT-DOMAIN-2: 2" "$WORK/out"
+curl -sf -X DELETE "$ENDPOINT/_fake/capacity" >/dev/null
+(cd "$WORK" && ./oci-insta-scale terminate -file fallback.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- launch falls back to the next shape when the first has no capacity"
+curl -sf -X PUT "$ENDPOINT/_fake/capacity" -d '{"shape": "VM.Standard.E4.Flex", "limit": 0}' >/dev/null
+(cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -shape VM.Standard.E4.Flex,VM.Standard.E5.Flex:2:16 -output shapes.json) > "$WORK/out" 2>&1
+expect "Summary: 2/2 instances created successfully" "$WORK/out"
+expect "VM.Standard.E5.Flex: 2" "$WORK/out"
+curl -sf -X DELETE "$ENDPOINT/_fake/capacity" >/dev/null
+(cd "$WORK" && ./oci-insta-scale terminate -file shapes.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- spread placement across ADs and fault domains"
+(cd "$WORK" && ./oci-insta-scale launch -instances 6 "${LAUNCH_FLAGS[@]}" -ad AD-1,AD-2,AD-3 -placement spread -output spread.json) > "$WORK/out" 2>&1
+expect "Spread plan: AD-1: 2 (FAULT-DOMAIN-1: 1, FAULT-DOMAIN-2: 1); AD-2: 2" "$WORK/out"
+expect "Summary: 6/6 instances created successfully" "$WORK/out"
+expect "AD-3/FAULT-DOMAIN-2: 1" "$WORK/out"
+(cd "$WORK" && ./oci-insta-scale terminate -file spread.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- shape config: fixed shapes send none, burstable flex shapes send a baseline"
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -shape VM.Standard2.1 -name fixed -output fixed.json) > "$WORK/out" 2>&1
+expect "Summary: 1/1 instances created successfully" "$WORK/out"
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -ocpus 2 -baseline 1/8 -name burst -output burst.json) > "$WORK/out" 2>&1
+expect "Shape: VM.Standard.E4.Flex (2 OCPU, 16 GB, baseline 1/8)" "$WORK/out"
+BURST_ID="$(jq -r '.instances[0].id' "$WORK/burst.json")"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r ".instances[] | select(.id == \"$BURST_ID\") | .shapeConfig.baselineOcpuUtilization")" = "BASELINE_1_8" ] \
+    || fail "expected burstable shape config on $BURST_ID"
+if (cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -shape BM.GPU4.8 -ocpus 8 -output gpu.json) > "$WORK/out" 2>&1; then
+    fail "expected a GPU shape config to be rejected"
+fi
+expect "GPU shape" "$WORK/out"
+# terminate still reads the legacy format of one OCID per line
+jq -r '.instances[].id' "$WORK/fixed.json" "$WORK/burst.json" > "$WORK/shaped.txt"
+(cd "$WORK" && ./oci-insta-scale terminate -file shaped.txt -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- launch from the pool config file with flag overrides"
+(cd "$WORK" && ./oci-insta-scale launch -config pool.yaml -instances 2 -name cfg -tags run=cfg -ttl 2h -endpoint "$ENDPOINT" -output cfg.json) > "$WORK/out" 2>&1
+expect "Shapes tried in order on capacity or limit errors: VM.Standard.E4.Flex (1 OCPU, 8 GB), VM.Standard.E5.Flex (1 OCPU, 8 GB)" "$WORK/out"
+expect "Summary: 2/2 instances created successfully" "$WORK/out"
+TAGGED="$(curl -sf "$ENDPOINT/_fake/state" | jq '[.instances[] | select(.freeformTags.run == "cfg" and .freeformTags.team == "e2e" and .metadata.ssh_authorized_keys == "ssh-ed25519 AAAAe2e")] | length')"
+[ "$TAGGED" = "2" ] || fail "expected 2 instances with tags and metadata from the config, found $TAGGED"
+STAMPED="$(curl -sf "$ENDPOINT/_fake/state" | jq --arg run "$(jq -r .run_id "$WORK/cfg.json")" --arg expires "$(jq -r '.expires_at | sub("\\.[0-9]+"; "")' "$WORK/cfg.json")" \
+    '[.instances[].freeformTags | select(.["oci-insta-scale-run-id"] == $run and .["oci-insta-scale-created-by"] == "ocid1.user.oc1..e2e"
+        and .["oci-insta-scale-version"] == "dev" and .["oci-insta-scale-created-at"] != null and .["oci-insta-scale-expires-at"] == $expires)] | length')"
+[ "$STAMPED" = "2" ] || fail "expected 2 instances with the standard tags and a 2h expiry, found $STAMPED"
+(cd "$WORK" && ./oci-insta-scale terminate -file cfg.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- launch with a non-retryable limit error"
+inject '{"op": "LaunchInstance", "error": "LimitExceeded", "times": 1}'
+if (cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -output capacity.json) > "$WORK/out" 2>&1; then
+    fail "expected a non-zero exit when a launch fails"
+fi
+expect "LimitExceeded" "$WORK/out"
+expect "Summary: 1/2 instances created successfully" "$WORK/out"
+[ "$(jq -r '[.instances[] | select(.state == "FAILED" and (.id | not) and (.error | test("LimitExceeded")))] | length' "$WORK/capacity.json")" = "1" ] \
+    || fail "expected the failed launch to be recorded in capacity.json"
+(cd "$WORK" && ./oci-insta-scale terminate -file capacity.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+# live <name prefix>: count the instances with that name prefix not yet terminated
+live() {
+    curl -sf "$ENDPOINT/_fake/state" | jq "[.instances[] | select((.displayName | startswith(\"$1\")) and .lifecycleState != \"TERMINATED\")] | length"
+}
+
+echo "--- a launch whose response is lost is retried with the same token"
+inject '{"op": "LaunchInstance", "error": "InternalError", "times": 1, "lost_response": true}'
+(cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -name lost -output lost.json) > "$WORK/out" 2>&1
+expect "Summary: 2/2 instances created successfully" "$WORK/out"
+[ "$(live lost-)" = "2" ] || fail "expected no duplicate instance after a lost launch response, found $(live lost-)"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r "[.instances[] | select(.displayName == \"lost-1\")][0].freeformTags[\"oci-insta-scale-run-id\"]")" = "$(jq -r .run_id "$WORK/lost.json")" ] \
+    || fail "expected instances to be tagged with the run ID"
+
+echo "--- a rerun of the same run adopts its instances; another run never does"
+LOST_RUN="$(jq -r .run_id "$WORK/lost.json")"
+(cd "$WORK" && ./oci-insta-scale launch -instances 3 "${LAUNCH_FLAGS[@]}" -name lost -run-id "$LOST_RUN" -output adopted.json) > "$WORK/out" 2>&1
+expect "lost-1 already exists as" "$WORK/out"
+expect "Existing instances adopted instead of launched: 2" "$WORK/out"
+expect "Summary: 3/3 instances created successfully" "$WORK/out"
+[ "$(live lost-)" = "3" ] || fail "expected 3 live instances after the rerun, found $(live lost-)"
+[ "$(jq '[.instances[] | select(.adopted)] | length' "$WORK/adopted.json")" = "2" ] || fail "expected 2 adopted instances in adopted.json"
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name lost -output dup.json) > "$WORK/out" 2>&1
+grep -q "already exists" "$WORK/out" && fail "expected a new run not to adopt another run's lost-1"
+[ "$(live lost-1)" = "2" ] || fail "expected a new run to launch its own lost-1"
+[ "$(jq -r '.instances[0].id' "$WORK/dup.json")" != "$(jq -r '.instances[0].id' "$WORK/adopted.json")" ] \
+    || fail "expected dup.json not to list the other run's lost-1"
+(cd "$WORK" && ./oci-insta-scale terminate -file dup.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+[ "$(live lost-)" = "3" ] || fail "expected terminating dup.json to leave the other run's 3 instances, found $(live lost-)"
+(cd "$WORK" && ./oci-insta-scale terminate -file adopted.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+[ "$(live lost-)" = "0" ] || fail "expected every lost- instance to be terminated"
+
+echo "--- launch with a scripted provisioning delay, terminate and wait until it is gone"
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "2s", "terminating": "0s"}' >/dev/null
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -output slow.json) > "$WORK/out" 2>&1
+expect "ready in: 10s" "$WORK/out"
+ID="$(jq -r '.instances[0].id' "$WORK/slow.json")"
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "0s", "terminating": "2s"}' >/dev/null
+(cd "$WORK" && ./oci-insta-scale terminate -file slow.json -wait -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+expect "Terminated $ID | attempts: 1 | terminated in: 10s" "$WORK/out"
+expect "Summary: 1/1 terminations accepted, 1/1 instances TERMINATED" "$WORK/out"
+expect "Time to TERMINATED: p50 10s" "$WORK/out"
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "0s", "terminating": "0s"}' >/dev/null
+
+echo "--- Ctrl-C stops a launch and terminates what it launched"
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "1h", "terminating": "0s"}' >/dev/null
+(cd "$WORK" && exec ./oci-insta-scale launch -instances 3 "${LAUNCH_FLAGS[@]}" -name abort -parallel 1 -terminate-on-interrupt -output aborted.json) > "$WORK/out" 2>&1 &
+LAUNCH_PID=$!
+for _ in $(seq 50); do
+    [ "$(jq '[.instances[] | select(.id)] | length' "$WORK/aborted.json" 2>/dev/null)" = "1" ] && break
+    sleep 0.1
+done
+kill -INT "$LAUNCH_PID"
+STATUS=0; wait "$LAUNCH_PID" || STATUS=$?
+[ "$STATUS" = "130" ] || { cat "$WORK/out"; fail "expected exit 130 after Ctrl-C, got $STATUS"; }
+expect "terminating the 1 instance(s) it launched" "$WORK/out"
+[ "$(live abort-)" = "0" ] || fail "expected the interrupted run's instance to be terminated"
+[ "$(jq -r '.instances[0].state' "$WORK/aborted.json")" = "TERMINATING" ] || fail "expected the terminated instance to be recorded in aborted.json"
+
+echo "--- resume a launch killed part way through"
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "1h", "terminating": "0s"}' >/dev/null
+(cd "$WORK" && exec ./oci-insta-scale launch -instances 3 "${LAUNCH_FLAGS[@]}" -parallel 1 -output resumed.json) > "$WORK/out" 2>&1 &
+LAUNCH_PID=$!
+for _ in $(seq 50); do
+    [ "$(jq '[.instances[] | select(.id)] | length' "$WORK/resumed.json" 2>/dev/null)" = "1" ] && break
+    sleep 0.1
+done
+kill -9 "$LAUNCH_PID"; wait "$LAUNCH_PID" 2>/dev/null || true
+[ "$(jq -r '.instances[0].state' "$WORK/resumed.json")" = "PROVISIONING" ] || fail "expected the first launch to be journaled before it was running"
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "0s", "terminating": "0s"}' >/dev/null
+(cd "$WORK" && ./oci-insta-scale resume -file resumed.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+expect "0/3 running, 1 in flight, 2 to launch" "$WORK/out"
+expect "Summary: 3/3 instances running" "$WORK/out"
+[ "$(jq '[.instances[] | select(.state == "RUNNING")] | length' "$WORK/resumed.json")" = "3" ] || fail "expected 3 running instances in resumed.json"
+(cd "$WORK" && ./oci-insta-scale resume -file resumed.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+expect "Nothing to resume" "$WORK/out"
+(cd "$WORK" && ./oci-insta-scale terminate -file resumed.json -compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+expect "Summary: 3/3 terminations accepted" "$WORK/out"
+
+echo "--- terminate the instances selected by name, tag and compartment subtree"
+curl -sf -X PUT "$ENDPOINT/_fake/compartments" -d '{"id": "ocid1.compartment.oc1..e2e-child", "parent": "ocid1.compartment.oc1..e2e"}' >/dev/null
+(cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -name sel -output sel.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name sel -compartment ocid1.compartment.oc1..e2e-child -adopt=false -output sel-child.json) > "$WORK/out" 2>&1
+SELECT=(-compartment ocid1.compartment.oc1..e2e -name-prefix sel- -endpoint "$ENDPOINT")
+"$WORK/oci-insta-scale" terminate "${SELECT[@]}" -dry-run > "$WORK/out" 2>&1
+expect "2 instance(s) match the selectors" "$WORK/out"
+expect "Dry run: nothing was terminated" "$WORK/out"
+"$WORK/oci-insta-scale" terminate "${SELECT[@]}" -tag "oci-insta-scale-run-id=$(jq -r .run_id "$WORK/sel-child.json")" -subtree -dry-run > "$WORK/out" 2>&1
+expect "1 instance(s) match the selectors" "$WORK/out"
+echo n | "$WORK/oci-insta-scale" terminate "${SELECT[@]}" -subtree > "$WORK/out" 2>&1
+expect "3 instance(s) match the selectors" "$WORK/out"
+expect "Nothing was terminated" "$WORK/out"
+[ "$(live sel-)" = "3" ] || fail "expected nothing to be terminated when the prompt is declined"
+echo y | "$WORK/oci-insta-scale" terminate "${SELECT[@]}" -subtree > "$WORK/out" 2>&1
+expect "Summary: 3/3 terminations accepted" "$WORK/out"
+[ "$(live sel-)" = "0" ] || fail "expected every selected instance to be terminated"
+STATUS=0; "$WORK/oci-insta-scale" terminate -name-prefix sel- -endpoint "$ENDPOINT" > "$WORK/out" 2>&1 || STATUS=$?
+[ "$STATUS" = "2" ] || fail "expected exit 2 when selecting without a compartment, got $STATUS"
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name bystander -output bystander.json) > "$WORK/out" 2>&1
+STATUS=0; "$WORK/oci-insta-scale" terminate -compartment ocid1.compartment.oc1..e2e -subtree -yes -endpoint "$ENDPOINT" > "$WORK/out" 2>&1 || STATUS=$?
+[ "$STATUS" = "2" ] || fail "expected exit 2 for -subtree without a selector, got $STATUS"
+STATUS=0; "$WORK/oci-insta-scale" terminate -compartment ocid1.compartment.oc1..e2e -name-prefix "" -yes -endpoint "$ENDPOINT" > "$WORK/out" 2>&1 || STATUS=$?
+[ "$STATUS" = "2" ] || fail "expected exit 2 for an empty selector, got $STATUS"
+expect "would select every instance" "$WORK/out"
+[ "$(live bystander)" = "1" ] || fail "expected a selector that filters nothing to terminate nothing"
+(cd "$WORK" && ./oci-insta-scale terminate -file bystander.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- terminate keeping boot volumes, then clean them up"
+(cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -name bv -output bv.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name bv-live -output bv-live.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale terminate -file bv.json -preserve-boot-volume -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+expect "Boot volumes were preserved" "$WORK/out"
+available() {
+    curl -sf "$ENDPOINT/_fake/state" | jq '[.bootVolumes[] | select(.lifecycleState == "AVAILABLE")] | length'
+}
+[ "$(available)" -ge 3 ] || fail "expected the preserved boot volumes to remain AVAILABLE"
+BV=(-compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT")
+"$WORK/oci-insta-scale" cleanup boot-volumes "${BV[@]}" -dry-run > "$WORK/out" 2>&1
+expect "2 unattached boot volume(s), 94 GB in total" "$WORK/out"
+expect "bv-1 (Boot Volume)" "$WORK/out"
+expect "Dry run: nothing was deleted" "$WORK/out"
+"$WORK/oci-insta-scale" cleanup boot-volumes "${BV[@]}" -older-than 1h > "$WORK/out" 2>&1
+expect "No unattached boot volumes match" "$WORK/out"
+BEFORE="$(available)"
+echo y | "$WORK/oci-insta-scale" cleanup boot-volumes "${BV[@]}" -ad AD-1 > "$WORK/out" 2>&1
+expect "Summary: 2/2 boot volumes deleted" "$WORK/out"
+[ "$(available)" = "$((BEFORE - 2))" ] || fail "expected only the 2 unattached boot volumes to be deleted"
+(cd "$WORK" && ./oci-insta-scale terminate -file bv-live.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+POOL=(-config "$WORK/pool.yaml" -endpoint "$ENDPOINT" -retry-base-delay 10ms)
+
+echo "--- pool create falls back to the next shape on a limit error, reporting an unused configuration it cannot delete"
+inject '{"op": "CreateInstancePool", "error": "LimitExceeded", "times": 1}'
+inject '{"op": "DeleteInstanceConfiguration", "status": 403, "code": "NotAuthorizedOrNotFound", "times": 1}'
+inject '{"op": "CreateInstancePool", "error": "InternalError", "times": 1, "lost_response": true}'
+"$WORK/oci-insta-scale" pool create "${POOL[@]}" -count 4 -ttl 3h -wait > "$WORK/out" 2>&1
+expect "with shape VM.Standard.E5.Flex" "$WORK/out"
+expect "Instance pool is RUNNING with 4/4 instances" "$WORK/out"
+expect "Time to RUNNING for 4 new instance(s)" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq '.instancePools | length')" = "1" ] || fail "expected a lost pool create response not to create a second pool"
+POOL_ID="$(grep -o 'ocid1\.instancepool[^)]*' "$WORK/out" | head -1)"
+POOL_RUN="$(grep -o 'run ID: [^)]*' "$WORK/out" | head -1 | cut -d' ' -f3)"
+STAMPED="$(curl -sf "$ENDPOINT/_fake/state" | jq --arg run "$POOL_RUN" '[.instancePools[], .instanceConfigurations[], .instances[] | .freeformTags
+    | select(.["oci-insta-scale-run-id"] == $run and .["oci-insta-scale-created-by"] == "ocid1.user.oc1..e2e" and .["oci-insta-scale-expires-at"] != null)] | length')"
+[ "$STAMPED" = "7" ] || fail "expected the pool, its 2 instance configurations and 4 instances to carry the standard tags, found $STAMPED"
+UNUSED_CONFIG="$(grep 'Unused instance configuration .* was not deleted' "$WORK/out" | grep -o 'ocid1\.instanceconfiguration[^ ]*')"
+[ -n "$UNUSED_CONFIG" ] || fail "expected the unused instance configuration that could not be deleted to be reported"
+
+echo "--- cleanup the unused instance configuration"
+"$WORK/oci-insta-scale" cleanup instance-configs -endpoint "$ENDPOINT" "$UNUSED_CONFIG" > "$WORK/out" 2>&1
+expect "Deleted instance configuration $UNUSED_CONFIG" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq '.instanceConfigurations | length')" = "1" ] || fail "expected 1 instance configuration after cleanup"
+
+echo "--- pool list, across pages of pool members"
+"$WORK/oci-insta-scale" pool list "${POOL[@]}" -pool-id "$POOL_ID" > "$WORK/out" 2>&1
+expect "Found 4 instances" "$WORK/out"
+expect "VM.Standard.E5.Flex: 4" "$WORK/out"
+
+echo "--- pool scale through a retried 503"
+inject '{"op": "UpdateInstancePool", "error": "ServiceUnavailable", "times": 1}'
+"$WORK/oci-insta-scale" pool scale "${POOL[@]}" -pool-id "$POOL_ID" -count 2 > "$WORK/out" 2>&1
+expect "Successfully scaled instance pool to 2 instances" "$WORK/out"
+
+echo "--- pool detach"
+"$WORK/oci-insta-scale" pool list "${POOL[@]}" -pool-id "$POOL_ID" > "$WORK/out" 2>&1
+INSTANCE_ID="$(grep -o 'ocid1\.instance\.[^ ]*' "$WORK/out" | head -1)"
+"$WORK/oci-insta-scale" pool detach "${POOL[@]}" -pool-id "$POOL_ID" -instance-id "$INSTANCE_ID" > "$WORK/out" 2>&1
+expect "Summary: 1/1 instances detached" "$WORK/out"
+expect "Instance pool is RUNNING with 1 instances" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r ".instancePools[0].size")" = "1" ] || fail "expected pool size 1 after detach"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r ".instances[] | select(.id == \"$INSTANCE_ID\") | .lifecycleState")" = "TERMINATED" ] \
+    || fail "expected the detached instance to be terminated"
+
+echo "--- pool detach by fault domain and age, keeping the pool size"
+# The pool's remaining instance must be older than the new provisioning time,
+# or the pool counts as still scaling
+sleep 3
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "3s", "terminating": "0s"}' >/dev/null
+"$WORK/oci-insta-scale" pool scale "${POOL[@]}" -pool-id "$POOL_ID" -count 4 -wait > "$WORK/out" 2>&1
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "0s", "terminating": "0s"}' >/dev/null
+expect "Provisioning → Running" "$WORK/out"
+expect "Instance pool is RUNNING with 4/4 instances" "$WORK/out"
+expect "Time to RUNNING for 3 new instance(s)" "$WORK/out"
+"$WORK/oci-insta-scale" pool list "${POOL[@]}" -pool-id "$POOL_ID" > "$WORK/out" 2>&1
+FD="$(grep -o 'Fault Domain: .*' "$WORK/out" | head -1 | cut -d' ' -f3)"
+IN_FD="$(grep -c "Fault Domain: $FD\$" "$WORK/out")"
+"$WORK/oci-insta-scale" pool detach "${POOL[@]}" -pool-id "$POOL_ID" -fd "$FD" -dry-run > "$WORK/out" 2>&1
+expect "$IN_FD of the pool's 4 instance(s) match the selectors" "$WORK/out"
+expect "Dry run: nothing was detached" "$WORK/out"
+"$WORK/oci-insta-scale" pool detach "${POOL[@]}" -pool-id "$POOL_ID" -oldest 2 -decrement=false -terminate=false -yes > "$WORK/out" 2>&1
+expect "letting the pool replace them and leaving them running" "$WORK/out"
+expect "Summary: 2/2 instances detached" "$WORK/out"
+expect "Instance pool is RUNNING with 4 instances" "$WORK/out"
+grep -o 'Detached ocid1\.instance\.[^ ]*' "$WORK/out" | cut -d' ' -f2 > "$WORK/detached.txt"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq --rawfile ids "$WORK/detached.txt" '[.instances[] | select((.id as $id | $ids | contains($id)) and .lifecycleState == "RUNNING")] | length')" = "2" ] \
+    || fail "expected the 2 detached instances to keep running outside the pool"
+(cd "$WORK" && ./oci-insta-scale terminate -file detached.txt -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+if "$WORK/oci-insta-scale" pool detach "${POOL[@]}" -pool-id "$POOL_ID" -instance-id ocid1.instance.oc1..nope > "$WORK/out" 2>&1; then
+    fail "expected detaching an instance outside the pool to fail"
+fi
+expect "is not in the pool" "$WORK/out"
+
+echo "--- pool scale by percentage in steps, and by a relative change held to min_size/max_size"
+"$WORK/oci-insta-scale" pool scale "${POOL[@]}" -pool-id "$POOL_ID" -max-step 2 200% > "$WORK/out" 2>&1
+expect "Scaling instance pool $POOL_ID to 6 instances (step 1/2)" "$WORK/out"
+expect "Scaling instance pool $POOL_ID to 8 instances (step 2/2)" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r ".instancePools[0].size")" = "8" ] || fail "expected pool size 8 after scaling to 200%"
+"$WORK/oci-insta-scale" pool list "${POOL[@]}" -pool-id "$POOL_ID" > "$WORK/out" 2>&1
+expect "Found 8 instances" "$WORK/out"
+"$WORK/oci-insta-scale" pool scale "${POOL[@]}" -pool-id "$POOL_ID" -2 > "$WORK/out" 2>&1
+expect "Scaling instance pool $POOL_ID from 8 to 6 instances" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r ".instancePools[0].size")" = "6" ] || fail "expected pool size 6 after scaling by -2 given after the flags"
+"$WORK/oci-insta-scale" pool scale "${POOL[@]}" -- "$POOL_ID" -1 > "$WORK/out" 2>&1
+expect "Scaling instance pool $POOL_ID from 6 to 5 instances" "$WORK/out"
+"$WORK/oci-insta-scale" pool scale "${POOL[@]}" "$POOL_ID" +1 > "$WORK/out" 2>&1
+expect "Scaling instance pool $POOL_ID from 5 to 6 instances" "$WORK/out"
+sed 's/^  display_name: "e2e-pool"$/&\n  min_size: 3\n  max_size: 10\n  max_step: 1/' "$WORK/pool.yaml" > "$WORK/bounded.yaml"
+BOUNDED=(-config "$WORK/bounded.yaml" -endpoint "$ENDPOINT" -retry-base-delay 10ms -pool-id "$POOL_ID")
+"$WORK/oci-insta-scale" pool scale "${BOUNDED[@]}" -max-step 0 -count -10 > "$WORK/out" 2>&1
+expect "Size -10 of 6 is -4, held to 3 by the pool's min_size/max_size" "$WORK/out"
+grep -q "(step" "$WORK/out" && fail "expected -max-step 0 to lift the config's max_step"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r ".instancePools[0].size")" = "3" ] || fail "expected pool size 3 after scaling down to min_size"
+if "$WORK/oci-insta-scale" pool scale "${BOUNDED[@]}" 12 > "$WORK/out" 2>&1; then
+    fail "expected an absolute size above max_size to be refused"
+fi
+expect "size 12 is above instance_pool.max_size 10" "$WORK/out"
+
+echo "--- pool scale in by policy, newest first in steps and refusing to remove protected instances"
+if "$WORK/oci-insta-scale" pool scale "${BOUNDED[@]}" -scale-in sideways -count -1 > "$WORK/out" 2>&1; then
+    fail "expected an unknown scale-in order to be refused"
+fi
+expect "unknown scale-in order \"sideways\"" "$WORK/out"
+if "$WORK/oci-insta-scale" pool scale "${POOL[@]}" -pool-id "$POOL_ID" -protect-tag team=e2e -count -1 > "$WORK/out" 2>&1; then
+    fail "expected scaling in with every instance protected to fail"
+fi
+expect "cannot remove 1 instances: only 0 of 3 are not protected (3 of the pool's 3 members are RUNNING)" "$WORK/out"
+"$WORK/oci-insta-scale" pool scale "${POOL[@]}" -pool-id "$POOL_ID" -scale-in newest -max-step 1 -wait -2 > "$WORK/out" 2>&1
+expect "Removing 2 instance(s), newest first" "$WORK/out"
+expect "to 2 instances (step 1/2)" "$WORK/out"
+expect "to 1 instances (step 2/2)" "$WORK/out"
+expect "Successfully scaled instance pool to 1 instances" "$WORK/out"
+expect "Instance pool is RUNNING with 1/1 instances" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq -r ".instancePools[0].size")" = "1" ] || fail "expected pool size 1 after scaling in"
+for VICTIM in $(grep -o 'Detached ocid1\.instance\.[^ ]*' "$WORK/out" | cut -d' ' -f2); do
+    curl -sf "$ENDPOINT/_fake/state" | jq -e --arg id "$VICTIM" '.instances[] | select(.id == $id) | .lifecycleState | test("TERMINAT")' >/dev/null \
+        || fail "expected the removed instance $VICTIM to be terminated"
+done
+
+echo "--- pool terminate"
+"$WORK/oci-insta-scale" pool terminate "${POOL[@]}" -pool-id "$POOL_ID" > "$WORK/out" 2>&1
+expect "Successfully terminated instance pool" "$WORK/out"
+
+echo "--- sweep stopped instances, the terminated pool's instance configuration and detached boot and block volumes"
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name swbv -output swbv.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale terminate -file swbv.json -preserve-boot-volume -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -name sw -output sw.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name swkeep -output swkeep.json) > "$WORK/out" 2>&1
+for ID in $(jq -r '.instances[].id' "$WORK/sw.json" "$WORK/swkeep.json"); do
+    curl -sf -X POST "$ENDPOINT/_fake/instances/$ID/state" -d '{"state": "STOPPED"}' >/dev/null
+done
+SW_RUN="$(jq -r .run_id "$WORK/sw.json")"
+curl -sf -X POST "$ENDPOINT/_fake/volumes" -d "{\"displayName\": \"sw-data\", \"instanceId\": \"$(jq -r '.instances[0].id' "$WORK/sw.json")\"}" >/dev/null
+curl -sf -X POST "$ENDPOINT/_fake/volumes" -d "{\"displayName\": \"sw-scratch\", \"compartmentId\": \"ocid1.compartment.oc1..e2e\",
+    \"availabilityDomain\": \"AD-1\", \"freeformTags\": {\"oci-insta-scale-run-id\": \"$SW_RUN\"}}" >/dev/null
+curl -sf -X POST "$ENDPOINT/_fake/volumes" -d '{"displayName": "not-ours", "compartmentId": "ocid1.compartment.oc1..e2e", "availabilityDomain": "AD-1"}' >/dev/null
+SW=(-compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT" -manifest "$WORK/swkeep.json")
+"$WORK/oci-insta-scale" sweep "${SW[@]}" > "$WORK/out" 2>&1
+expect "Nothing left behind" "$WORK/out"
+"$WORK/oci-insta-scale" sweep "${SW[@]}" -older-than 0s -dry-run > "$WORK/out" 2>&1
+expect "5 resource(s) left behind" "$WORK/out"
+expect "sw-2 .*STOPPED" "$WORK/out"
+expect "no live pool" "$WORK/out"
+expect "boot-volumes .*swbv-1 (Boot Volume) .*unattached .*47 GB" "$WORK/out"
+expect "volumes .*sw-scratch .*unattached .*50 GB" "$WORK/out"
+expect "Storage held: 191 GB, estimated \$8.12 per month" "$WORK/out"
+grep -q "swkeep" "$WORK/out" && fail "expected the instance in -manifest to be kept"
+grep -q "sw-data" "$WORK/out" && fail "expected a block volume attached to an instance to be kept"
+grep -q "not-ours" "$WORK/out" && fail "expected a block volume the tool did not create to be kept"
+expect "Dry run: nothing was deleted" "$WORK/out"
+"$WORK/oci-insta-scale" sweep "${SW[@]}" -older-than 0s -yes > "$WORK/out" 2>&1
+expect "Summary: 5/5 resources deleted" "$WORK/out"
+expect "Deleted block volume" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq '.instanceConfigurations | length')" = "0" ] || fail "expected the sweep to delete the unused instance configuration"
+"$WORK/oci-insta-scale" sweep "${SW[@]}" -kinds volumes -older-than 0s -yes > "$WORK/out" 2>&1
+expect "volumes .*sw-data .*unattached" "$WORK/out"
+expect "Summary: 1/1 resources deleted" "$WORK/out"
+[ "$(curl -sf "$ENDPOINT/_fake/state" | jq '[.volumes[] | select(.lifecycleState == "AVAILABLE")] | map(.displayName) | join(",")')" = '"not-ours"' ] \
+    || fail "expected only the block volume the tool did not create to survive the sweep"
+(cd "$WORK" && ./oci-insta-scale terminate -file swkeep.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+echo "--- pool create -wait fails when the pool is only partly provisioned"
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "1h", "terminating": "0s"}' >/dev/null
+if "$WORK/oci-insta-scale" pool create "${POOL[@]}" -count 2 -name partial -wait -wait-timeout 2s > "$WORK/out" 2>&1; then
+    fail "expected pool create -wait to fail while its instances are still provisioning"
+fi
+curl -sf -X PUT "$ENDPOINT/_fake/timeline" -d '{"provisioning": "0s", "terminating": "0s"}' >/dev/null
+expect "only partly provisioned after 2s: PROVISIONING with 0/2 instances running" "$WORK/out"
+PARTIAL_ID="$(grep -o 'ocid1\.instancepool[^)]*' "$WORK/out" | head -1)"
+"$WORK/oci-insta-scale" pool terminate "${POOL[@]}" -pool-id "$PARTIAL_ID" > "$WORK/out" 2>&1
+
+echo "--- reap expired instances and pools, sparing excluded and unexpired ones"
+(cd "$WORK" && ./oci-insta-scale launch -instances 2 "${LAUNCH_FLAGS[@]}" -name reaped -ttl 1s -output reaped.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name kept -ttl 1s -tags oci-insta-scale-keep=yes -output kept.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name perf -ttl 1s -tags team=perf -output perf.json) > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale launch -instances 1 "${LAUNCH_FLAGS[@]}" -name later -ttl 10h -output later.json) > "$WORK/out" 2>&1
+"$WORK/oci-insta-scale" pool create "${POOL[@]}" -count 2 -name reaped-pool -ttl 1s > "$WORK/out" 2>&1
+sleep 2
+RP=(-compartment ocid1.compartment.oc1..e2e -endpoint "$ENDPOINT" -exclude-tag team=perf -audit-log "$WORK/reap.jsonl")
+"$WORK/oci-insta-scale" reap "${RP[@]}" -dry-run > "$WORK/out" 2>&1
+expect "3 resource(s) expired" "$WORK/out"
+expect "instance-pool  reaped-pool" "$WORK/out"
+expect "Dry run: nothing was terminated" "$WORK/out"
+[ ! -e "$WORK/reap.jsonl" ] || fail "expected a dry run not to write the audit log"
+"$WORK/oci-insta-scale" reap "${RP[@]}" > "$WORK/out" 2>&1
+expect "Summary: 3/3 expired resources terminated" "$WORK/out"
+[ "$(jq -s '[.[] | select(.result == "terminated")] | group_by(.kind) | map({(.[0].kind): length}) | add' "$WORK/reap.jsonl" | jq -c .)" = '{"instance":2,"instance-pool":1}' ] \
+    || fail "expected the audit log to record 2 instances and 1 pool"
+[ "$(live reaped)" = "0" ] || fail "expected the expired instances and pool members to be terminated"
+[ "$(live kept)$(live perf)$(live later)" = "111" ] || fail "expected the kept, excluded and unexpired instances to survive the reap"
+STATUS=0; timeout --foreground --preserve-status -s INT 3 "$WORK/oci-insta-scale" reap "${RP[@]}" -interval 1s > "$WORK/out" 2>&1 || STATUS=$?
+[ "$STATUS" = "130" ] || fail "expected exit 130 when the reap loop is interrupted, got $STATUS"
+expect "Nothing has expired" "$WORK/out"
+expect "Stopped reaping" "$WORK/out"
+(cd "$WORK" && ./oci-insta-scale terminate -file kept.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale terminate -file perf.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+(cd "$WORK" && ./oci-insta-scale terminate -file later.json -endpoint "$ENDPOINT") > "$WORK/out" 2>&1
+
+LEFT="$(curl -sf "$ENDPOINT/_fake/state" | jq '[.instances[] | select(.lifecycleState != "TERMINATED")] | length')"
+[ "$LEFT" = "0" ] || fail "expected no live instances at the end, found $LEFT"
+
+echo "All end-to-end checks passed."
@@ -0,0 +1,132 @@
+package ocifake
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ServiceError mimics the errors returned by the OCI SDK for failed service
+// calls. It satisfies common.ServiceError, so code that classifies errors with
+// common.IsServiceError treats it exactly like a real API failure.
+type ServiceError struct {
+	StatusCode   int
+	Code         string
+	Message      string
+	OpcRequestID string
+}
+
+func (e ServiceError) Error() string {
+	return fmt.Sprintf("Error returned by fake OCI service. Http Status Code: %d. Error Code: %s. Message: %s",
+		e.StatusCode, e.Code, e.Message)
+}
+
+// GetHTTPStatusCode returns the HTTP status code of the error
+func (e ServiceError) GetHTTPStatusCode() int { return e.StatusCode }
+
+// GetMessage returns the human-readable error message
+func (e ServiceError) GetMessage() string { return e.Message }
+
+// GetCode returns the OCI error code
+func (e ServiceError) GetCode() string { return e.Code }
+
+// GetOpcRequestID returns the request ID of the failed call
+func (e ServiceError) GetOpcRequestID() string { return e.OpcRequestID }
+
+// TooManyRequests returns the error OCI sends when a tenancy is throttled
+func TooManyRequests() ServiceError {
+	return ServiceError{StatusCode: http.StatusTooManyRequests, Code: "TooManyRequests", Message: "Too many requests for the user"}
+}
+
+// InternalError returns a generic 500 error
+func InternalError() ServiceError {
+	return ServiceError{StatusCode: http.StatusInternalServerError, Code: "InternalError", Message: "Internal error occurred"}
+}
+
+// ServiceUnavailable returns a 503 error
+func ServiceUnavailable() ServiceError {
+	return ServiceError{StatusCode: http.StatusServiceUnavailable, Code: "ServiceUnavailable", Message: "Service is temporarily unavailable"}
+}
+
+// OutOfCapacity returns the error OCI sends when an AD has no host capacity
+// left for the requested shape
+func OutOfCapacity() ServiceError {
+	return ServiceError{StatusCode: http.StatusInternalServerError, Code: "InternalError", Message: "Out of host capacity."}
+}
+
+// LimitExceeded returns the error OCI sends when a service limit would be exceeded
+func LimitExceeded() ServiceError {
+	return ServiceError{StatusCode: http.StatusBadRequest, Code: "LimitExceeded", Message: "The following service limits were exceeded: standard-e4-core-count"}
+}
+
+// NotFound returns the error OCI sends for a missing resource
+func NotFound(id string) ServiceError {
+	return ServiceError{StatusCode: http.StatusNotFound, Code: "NotAuthorizedOrNotFound", Message: fmt.Sprintf("Authorization failed or requested resource not found: %s", id)}
+}
+
+// Conflict returns the error OCI sends when a resource is in the wrong state
+// for the requested operation
+func Conflict(msg string) ServiceError {
+	return ServiceError{StatusCode: http.StatusConflict, Code: "IncorrectState", Message: msg}
+}
+
+// fault is an error injected into the next Times calls of an operation
+type fault struct {
+	op    string
+	err   error
+	times int  // remaining calls to fail; <= 0 means every call
+	lost  bool // the call takes effect and only its response is lost
+}
+
+// InjectError makes the next times calls to op fail with err. A times value of
+// 0 or less fails every call until ClearFaults is called. Faults are consumed in
+// the order they were injected.
+func (b *Backend) InjectError(op string, err error, times int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.faults = append(b.faults, &fault{op: op, err: err, times: times})
+}
+
+// InjectLostResponse makes the next times calls to op succeed but return err,
+// as if the response was lost on the way back. The client cannot tell the
+// resource was created, which is what retry tokens guard against. Only the
+// create operations (LaunchInstance, CreateInstanceConfiguration and
+// CreateInstancePool) support it.
+func (b *Backend) InjectLostResponse(op string, err error, times int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.faults = append(b.faults, &fault{op: op, err: err, times: times, lost: true})
+}
+
+// ClearFaults removes every injected error
+func (b *Backend) ClearFaults() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.faults = nil
+}
+
+// takeFault returns the injected error for op, if any. Callers must hold b.mu.
+func (b *Backend) takeFault(op string) error {
+	return b.take(op, false)
+}
+
+// takeLostResponse returns the error replacing the response of a create op
+// that has taken effect, if any. Callers must hold b.mu.
+func (b *Backend) takeLostResponse(op string) error {
+	return b.take(op, true)
+}
+
+func (b *Backend) take(op string, lost bool) error {
+	for i, f := range b.faults {
+		if f.op != op || f.lost != lost {
+			continue
+		}
+		if f.times > 0 {
+			f.times--
+			if f.times == 0 {
+				b.faults = append(b.faults[:i], b.faults[i+1:]...)
+			}
+		}
+		return f.err
+	}
+	return nil
+}
@@ -1,8 +1,8 @@
-module github.com/tomarkel/oci-insta-scale
+module github.com/tomarkel/oci-insta-scale/ocifake
 
 go 1.21
 
-require github.com/oracle/oci-go-sdk/v65 v65.54.0
+require github.com/oracle/oci-go-sdk/v65 v65.55.0
 
 require (
 	github.com/gofrs/flock v0.8.1 // indirect
@@ -0,0 +1,72 @@
+package ocifake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+// Compartment describes a simulated compartment in a scenario file or admin
+// request. Parent is the compartment it sits in.
+type Compartment struct {
+	ID     string `json:"id"`
+	Parent string `json:"parent"`
+	Name   string `json:"name,omitempty"`
+}
+
+// AddCompartment adds c to the compartment tree, replacing any compartment
+// with the same ID
+func (b *Backend) AddCompartment(c Compartment) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c.Name == "" {
+		c.Name = c.ID
+	}
+	b.compartments[c.ID] = c
+}
+
+// ListCompartments simulates identity.IdentityClient.ListCompartments. It
+// returns the compartments directly inside the requested one, or with
+// CompartmentIdInSubtree every compartment beneath it.
+func (b *Backend) ListCompartments(ctx context.Context, request identity.ListCompartmentsRequest) (identity.ListCompartmentsResponse, error) {
+	if err := b.begin(ctx, OpListCompartments); err != nil {
+		return identity.ListCompartmentsResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subtree := request.CompartmentIdInSubtree != nil && *request.CompartmentIdInSubtree
+	items := []identity.Compartment{} // the SDK cannot unmarshal a null list
+	for _, c := range b.compartments {
+		if b.within(c, derefString(request.CompartmentId), subtree) {
+			items = append(items, identity.Compartment{
+				Id:             common.String(c.ID),
+				CompartmentId:  common.String(c.Parent),
+				Name:           common.String(c.Name),
+				Description:    common.String(fmt.Sprintf("Simulated compartment %s", c.Name)),
+				LifecycleState: identity.CompartmentLifecycleStateActive,
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+	return identity.ListCompartmentsResponse{Items: items}, nil
+}
+
+// within reports whether c sits directly in parent, or anywhere beneath it
+// when subtree is set
+func (b *Backend) within(c Compartment, parent string, subtree bool) bool {
+	for depth := 0; depth < len(b.compartments); depth++ {
+		if c.Parent == parent {
+			return true
+		}
+		next, ok := b.compartments[c.Parent]
+		if !subtree || !ok {
+			return false
+		}
+		c = next
+	}
+	return false
+}
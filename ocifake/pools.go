@@ -0,0 +1,492 @@
+package ocifake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// poolInstancesPageSize is how many members ListInstancePoolInstances returns
+// per page when the request sets no limit. It is kept smaller than most pools
+// so a client that ignores opc-next-page misses members.
+const poolInstancesPageSize = 3
+
+// pool is the simulated state of an instance pool
+type pool struct {
+	details      core.InstancePool
+	launch       core.InstanceConfigurationLaunchInstanceDetails
+	members      []string
+	scaled       bool
+	terminatedAt time.Time
+	cursor       int // next placement slot, used to spread members across ADs and FDs
+}
+
+// poolState derives the lifecycle state of p at now from its members
+func (b *Backend) poolState(p *pool, now time.Time) core.InstancePoolLifecycleStateEnum {
+	if !p.terminatedAt.IsZero() {
+		if now.Sub(p.terminatedAt) >= b.timeline.Terminating {
+			return core.InstancePoolLifecycleStateTerminated
+		}
+		return core.InstancePoolLifecycleStateTerminating
+	}
+	for _, id := range p.members {
+		if b.state(b.instances[id], now) != core.InstanceLifecycleStateRunning {
+			if p.scaled {
+				return core.InstancePoolLifecycleStateScaling
+			}
+			return core.InstancePoolLifecycleStateProvisioning
+		}
+	}
+	return core.InstancePoolLifecycleStateRunning
+}
+
+// poolSnapshot returns a copy of p with its current size and state filled in
+func (b *Backend) poolSnapshot(p *pool) core.InstancePool {
+	out := p.details
+	out.Size = common.Int(len(p.members))
+	out.LifecycleState = b.poolState(p, b.now())
+	return out
+}
+
+// Pools returns a snapshot of every instance pool, ordered by creation
+func (b *Backend) Pools() []core.InstancePool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]core.InstancePool, 0, len(b.pools))
+	for _, p := range b.pools {
+		out = append(out, b.poolSnapshot(p))
+	}
+	sort.Slice(out, func(i, j int) bool { return *out[i].Id < *out[j].Id })
+	return out
+}
+
+// InstanceConfigurations returns every instance configuration, ordered by creation
+func (b *Backend) InstanceConfigurations() []core.InstanceConfiguration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]core.InstanceConfiguration, 0, len(b.configs))
+	for _, c := range b.configs {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool { return *out[i].Id < *out[j].Id })
+	return out
+}
+
+// resize launches or terminates members until p has size instances.
+// New members are placed round-robin across the placement configurations and
+// their fault domains. Callers must hold b.mu.
+func (b *Backend) resize(p *pool, size int) {
+	placements := p.details.PlacementConfigurations
+	for len(p.members) < size {
+		placement := placements[p.cursor%len(placements)]
+		fd := fmt.Sprintf("FAULT-DOMAIN-%d", (p.cursor/len(placements))%3+1)
+		if len(placement.FaultDomains) > 0 {
+			fd = placement.FaultDomains[(p.cursor/len(placements))%len(placement.FaultDomains)]
+		}
+		p.cursor++
+
+		var imageID *string
+		if src, ok := p.launch.SourceDetails.(core.InstanceConfigurationInstanceSourceViaImageDetails); ok {
+			imageID = src.ImageId
+		}
+		name := fmt.Sprintf("inst-%06d", b.seq+1)
+		if p.details.DisplayName != nil {
+			name += "-" + *p.details.DisplayName
+		}
+		inst := b.launch(core.Instance{
+			AvailabilityDomain:      placement.AvailabilityDomain,
+			CompartmentId:           p.details.CompartmentId,
+			DisplayName:             common.String(name),
+			Shape:                   p.launch.Shape,
+			ImageId:                 imageID,
+			FaultDomain:             common.String(fd),
+			FreeformTags:            p.launch.FreeformTags,
+			DefinedTags:             p.launch.DefinedTags,
+			Metadata:                p.launch.Metadata,
+			InstanceConfigurationId: p.details.InstanceConfigurationId,
+		})
+		p.members = append(p.members, *inst.details.Id)
+	}
+	for len(p.members) > size {
+		last := p.members[len(p.members)-1]
+		b.terminate(b.instances[last], false)
+		p.members = p.members[:len(p.members)-1]
+	}
+}
+
+// lookupPool returns the pool with the given ID. Callers must hold b.mu.
+func (b *Backend) lookupPool(id *string) (*pool, error) {
+	p, ok := b.pools[derefString(id)]
+	if !ok {
+		return nil, NotFound(derefString(id))
+	}
+	return p, nil
+}
+
+// requireRunning rejects changes to a pool that is still provisioning, scaling
+// or terminating, matching the 409 the real service returns. Callers must hold b.mu.
+func (b *Backend) requireRunning(p *pool) error {
+	if state := b.poolState(p, b.now()); state != core.InstancePoolLifecycleStateRunning {
+		return Conflict(fmt.Sprintf("Instance pool %s is in state %s", *p.details.Id, state))
+	}
+	return nil
+}
+
+// CreateInstanceConfiguration simulates core.ComputeManagementClient.CreateInstanceConfiguration
+func (b *Backend) CreateInstanceConfiguration(ctx context.Context, request core.CreateInstanceConfigurationRequest) (core.CreateInstanceConfigurationResponse, error) {
+	if err := b.begin(ctx, OpCreateInstanceConfiguration); err != nil {
+		return core.CreateInstanceConfigurationResponse{}, err
+	}
+
+	var cfg core.InstanceConfiguration
+	switch d := request.CreateInstanceConfiguration.(type) {
+	case core.CreateInstanceConfigurationDetails:
+		cfg = core.InstanceConfiguration{
+			CompartmentId:   d.CompartmentId,
+			DisplayName:     d.DisplayName,
+			FreeformTags:    d.FreeformTags,
+			DefinedTags:     d.DefinedTags,
+			InstanceDetails: d.InstanceDetails,
+		}
+	case *core.CreateInstanceConfigurationDetails:
+		cfg = core.InstanceConfiguration{
+			CompartmentId:   d.CompartmentId,
+			DisplayName:     d.DisplayName,
+			FreeformTags:    d.FreeformTags,
+			DefinedTags:     d.DefinedTags,
+			InstanceDetails: d.InstanceDetails,
+		}
+	default:
+		return core.CreateInstanceConfigurationResponse{}, ServiceError{StatusCode: 400, Code: "InvalidParameter", Message: fmt.Sprintf("unsupported instance configuration source %T", d)}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if id, ok := b.retried(OpCreateInstanceConfiguration, request.OpcRetryToken); ok {
+		if existing, ok := b.configs[id]; ok {
+			return core.CreateInstanceConfigurationResponse{InstanceConfiguration: *existing}, nil
+		}
+	}
+	cfg.Id = common.String(b.nextID("instanceconfiguration"))
+	cfg.TimeCreated = &common.SDKTime{Time: b.now()}
+	b.configs[*cfg.Id] = &cfg
+	b.remember(OpCreateInstanceConfiguration, request.OpcRetryToken, *cfg.Id)
+	if err := b.takeLostResponse(OpCreateInstanceConfiguration); err != nil {
+		return core.CreateInstanceConfigurationResponse{}, err
+	}
+	return core.CreateInstanceConfigurationResponse{InstanceConfiguration: cfg}, nil
+}
+
+// DeleteInstanceConfiguration simulates core.ComputeManagementClient.DeleteInstanceConfiguration.
+// Like the real service it refuses to delete a configuration a live pool uses.
+func (b *Backend) DeleteInstanceConfiguration(ctx context.Context, request core.DeleteInstanceConfigurationRequest) (core.DeleteInstanceConfigurationResponse, error) {
+	if err := b.begin(ctx, OpDeleteInstanceConfiguration); err != nil {
+		return core.DeleteInstanceConfigurationResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := derefString(request.InstanceConfigurationId)
+	if _, ok := b.configs[id]; !ok {
+		return core.DeleteInstanceConfigurationResponse{}, NotFound(id)
+	}
+	for _, p := range b.pools {
+		if derefString(p.details.InstanceConfigurationId) == id && p.terminatedAt.IsZero() {
+			return core.DeleteInstanceConfigurationResponse{}, Conflict(fmt.Sprintf("Instance configuration %s is used by instance pool %s", id, *p.details.Id))
+		}
+	}
+	delete(b.configs, id)
+	return core.DeleteInstanceConfigurationResponse{}, nil
+}
+
+// ListInstanceConfigurations simulates
+// core.ComputeManagementClient.ListInstanceConfigurations. All configurations
+// in the compartment are returned in a single page.
+func (b *Backend) ListInstanceConfigurations(ctx context.Context, request core.ListInstanceConfigurationsRequest) (core.ListInstanceConfigurationsResponse, error) {
+	if err := b.begin(ctx, OpListInstanceConfigurations); err != nil {
+		return core.ListInstanceConfigurationsResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := []core.InstanceConfigurationSummary{} // the SDK cannot unmarshal a null list
+	for _, c := range b.configs {
+		if derefString(c.CompartmentId) != derefString(request.CompartmentId) {
+			continue
+		}
+		items = append(items, core.InstanceConfigurationSummary{
+			Id:            c.Id,
+			CompartmentId: c.CompartmentId,
+			DisplayName:   c.DisplayName,
+			TimeCreated:   c.TimeCreated,
+			FreeformTags:  c.FreeformTags,
+			DefinedTags:   c.DefinedTags,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+	return core.ListInstanceConfigurationsResponse{Items: items}, nil
+}
+
+// CreateInstancePool simulates core.ComputeManagementClient.CreateInstancePool
+func (b *Backend) CreateInstancePool(ctx context.Context, request core.CreateInstancePoolRequest) (core.CreateInstancePoolResponse, error) {
+	if err := b.begin(ctx, OpCreateInstancePool); err != nil {
+		return core.CreateInstancePoolResponse{}, err
+	}
+
+	d := request.CreateInstancePoolDetails
+	if len(d.PlacementConfigurations) == 0 {
+		return core.CreateInstancePoolResponse{}, ServiceError{StatusCode: 400, Code: "InvalidParameter", Message: "placementConfigurations must not be empty"}
+	}
+	if d.Size == nil || *d.Size < 0 {
+		return core.CreateInstancePoolResponse{}, ServiceError{StatusCode: 400, Code: "InvalidParameter", Message: "size must be zero or greater"}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if id, ok := b.retried(OpCreateInstancePool, request.OpcRetryToken); ok {
+		return core.CreateInstancePoolResponse{InstancePool: b.poolSnapshot(b.pools[id])}, nil
+	}
+	cfg, ok := b.configs[derefString(d.InstanceConfigurationId)]
+	if !ok {
+		return core.CreateInstancePoolResponse{}, NotFound(derefString(d.InstanceConfigurationId))
+	}
+
+	p := &pool{
+		details: core.InstancePool{
+			Id:                      common.String(b.nextID("instancepool")),
+			CompartmentId:           d.CompartmentId,
+			InstanceConfigurationId: d.InstanceConfigurationId,
+			DisplayName:             d.DisplayName,
+			FreeformTags:            d.FreeformTags,
+			DefinedTags:             d.DefinedTags,
+			TimeCreated:             &common.SDKTime{Time: b.now()},
+		},
+	}
+	for _, pc := range d.PlacementConfigurations {
+		p.details.PlacementConfigurations = append(p.details.PlacementConfigurations, core.InstancePoolPlacementConfiguration{
+			AvailabilityDomain: pc.AvailabilityDomain,
+			PrimarySubnetId:    pc.PrimarySubnetId,
+			FaultDomains:       pc.FaultDomains,
+		})
+	}
+	for _, lb := range d.LoadBalancers {
+		p.details.LoadBalancers = append(p.details.LoadBalancers, core.InstancePoolLoadBalancerAttachment{
+			InstancePoolId: p.details.Id,
+			LoadBalancerId: lb.LoadBalancerId,
+			BackendSetName: lb.BackendSetName,
+			Port:           lb.Port,
+			VnicSelection:  lb.VnicSelection,
+		})
+	}
+	switch cd := cfg.InstanceDetails.(type) {
+	case core.ComputeInstanceDetails:
+		if cd.LaunchDetails != nil {
+			p.launch = *cd.LaunchDetails
+		}
+	case *core.ComputeInstanceDetails:
+		if cd.LaunchDetails != nil {
+			p.launch = *cd.LaunchDetails
+		}
+	}
+
+	b.pools[*p.details.Id] = p
+	b.resize(p, *d.Size)
+	b.remember(OpCreateInstancePool, request.OpcRetryToken, *p.details.Id)
+	if err := b.takeLostResponse(OpCreateInstancePool); err != nil {
+		return core.CreateInstancePoolResponse{}, err
+	}
+	return core.CreateInstancePoolResponse{InstancePool: b.poolSnapshot(p)}, nil
+}
+
+// UpdateInstancePool simulates core.ComputeManagementClient.UpdateInstancePool.
+// Only size and display name changes are modelled.
+func (b *Backend) UpdateInstancePool(ctx context.Context, request core.UpdateInstancePoolRequest) (core.UpdateInstancePoolResponse, error) {
+	if err := b.begin(ctx, OpUpdateInstancePool); err != nil {
+		return core.UpdateInstancePoolResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, err := b.lookupPool(request.InstancePoolId)
+	if err != nil {
+		return core.UpdateInstancePoolResponse{}, err
+	}
+	if err := b.requireRunning(p); err != nil {
+		return core.UpdateInstancePoolResponse{}, err
+	}
+
+	d := request.UpdateInstancePoolDetails
+	if d.DisplayName != nil {
+		p.details.DisplayName = d.DisplayName
+	}
+	if d.Size != nil {
+		if *d.Size < 0 {
+			return core.UpdateInstancePoolResponse{}, ServiceError{StatusCode: 400, Code: "InvalidParameter", Message: "size must be zero or greater"}
+		}
+		p.scaled = true
+		b.resize(p, *d.Size)
+	}
+	return core.UpdateInstancePoolResponse{InstancePool: b.poolSnapshot(p)}, nil
+}
+
+// GetInstancePool simulates core.ComputeManagementClient.GetInstancePool
+func (b *Backend) GetInstancePool(ctx context.Context, request core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error) {
+	if err := b.begin(ctx, OpGetInstancePool); err != nil {
+		return core.GetInstancePoolResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, err := b.lookupPool(request.InstancePoolId)
+	if err != nil {
+		return core.GetInstancePoolResponse{}, err
+	}
+	return core.GetInstancePoolResponse{InstancePool: b.poolSnapshot(p)}, nil
+}
+
+// ListInstancePools simulates core.ComputeManagementClient.ListInstancePools,
+// including terminated pools as the service does. All pools in the
+// compartment are returned in a single page.
+func (b *Backend) ListInstancePools(ctx context.Context, request core.ListInstancePoolsRequest) (core.ListInstancePoolsResponse, error) {
+	if err := b.begin(ctx, OpListInstancePools); err != nil {
+		return core.ListInstancePoolsResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := []core.InstancePoolSummary{} // the SDK cannot unmarshal a null list
+	for _, p := range b.pools {
+		if derefString(p.details.CompartmentId) != derefString(request.CompartmentId) {
+			continue
+		}
+		snap := b.poolSnapshot(p)
+		state := core.InstancePoolSummaryLifecycleStateEnum(snap.LifecycleState)
+		if request.LifecycleState != "" && request.LifecycleState != state {
+			continue
+		}
+		var ads []string
+		for _, pc := range snap.PlacementConfigurations {
+			ads = append(ads, derefString(pc.AvailabilityDomain))
+		}
+		items = append(items, core.InstancePoolSummary{
+			Id:                      snap.Id,
+			CompartmentId:           snap.CompartmentId,
+			InstanceConfigurationId: snap.InstanceConfigurationId,
+			LifecycleState:          state,
+			AvailabilityDomains:     ads,
+			Size:                    snap.Size,
+			TimeCreated:             snap.TimeCreated,
+			DisplayName:             snap.DisplayName,
+			FreeformTags:            snap.FreeformTags,
+			DefinedTags:             snap.DefinedTags,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+	return core.ListInstancePoolsResponse{Items: items}, nil
+}
+
+// TerminateInstancePool simulates core.ComputeManagementClient.TerminateInstancePool,
+// terminating every member along with the pool
+func (b *Backend) TerminateInstancePool(ctx context.Context, request core.TerminateInstancePoolRequest) (core.TerminateInstancePoolResponse, error) {
+	if err := b.begin(ctx, OpTerminateInstancePool); err != nil {
+		return core.TerminateInstancePoolResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, err := b.lookupPool(request.InstancePoolId)
+	if err != nil {
+		return core.TerminateInstancePoolResponse{}, err
+	}
+	for _, id := range p.members {
+		b.terminate(b.instances[id], false)
+	}
+	p.members = nil
+	if p.terminatedAt.IsZero() {
+		p.terminatedAt = b.now()
+	}
+	return core.TerminateInstancePoolResponse{}, nil
+}
+
+// ListInstancePoolInstances simulates core.ComputeManagementClient.ListInstancePoolInstances,
+// returning members poolInstancesPageSize at a time unless the request sets a limit
+func (b *Backend) ListInstancePoolInstances(ctx context.Context, request core.ListInstancePoolInstancesRequest) (core.ListInstancePoolInstancesResponse, error) {
+	if err := b.begin(ctx, OpListInstancePoolInstances); err != nil {
+		return core.ListInstancePoolInstancesResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, err := b.lookupPool(request.InstancePoolId)
+	if err != nil {
+		return core.ListInstancePoolInstancesResponse{}, err
+	}
+
+	items := make([]core.InstanceSummary, 0, len(p.members))
+	for _, id := range p.members {
+		inst := b.snapshot(b.instances[id])
+		items = append(items, core.InstanceSummary{
+			Id:                      inst.Id,
+			AvailabilityDomain:      inst.AvailabilityDomain,
+			CompartmentId:           inst.CompartmentId,
+			InstanceConfigurationId: inst.InstanceConfigurationId,
+			Region:                  inst.Region,
+			State:                   common.String(displayState(inst.LifecycleState)),
+			TimeCreated:             inst.TimeCreated,
+			DisplayName:             inst.DisplayName,
+			FaultDomain:             inst.FaultDomain,
+			Shape:                   inst.Shape,
+		})
+	}
+	var resp core.ListInstancePoolInstancesResponse
+	resp.Items, resp.OpcNextPage = page(items, request.Page, request.Limit, poolInstancesPageSize)
+	return resp, nil
+}
+
+// DetachInstancePoolInstance simulates core.ComputeManagementClient.DetachInstancePoolInstance.
+// Without IsDecrementSize=false the pool shrinks; otherwise a replacement is
+// launched. IsAutoTerminate terminates the detached instance.
+func (b *Backend) DetachInstancePoolInstance(ctx context.Context, request core.DetachInstancePoolInstanceRequest) (core.DetachInstancePoolInstanceResponse, error) {
+	if err := b.begin(ctx, OpDetachInstancePoolInstance); err != nil {
+		return core.DetachInstancePoolInstanceResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, err := b.lookupPool(request.InstancePoolId)
+	if err != nil {
+		return core.DetachInstancePoolInstanceResponse{}, err
+	}
+	if err := b.requireRunning(p); err != nil {
+		return core.DetachInstancePoolInstanceResponse{}, err
+	}
+
+	d := request.DetachInstancePoolInstanceDetails
+	instanceID := derefString(d.InstanceId)
+	idx := -1
+	for i, id := range p.members {
+		if id == instanceID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return core.DetachInstancePoolInstanceResponse{}, NotFound(instanceID)
+	}
+
+	size := len(p.members)
+	p.members = append(p.members[:idx], p.members[idx+1:]...)
+	p.scaled = true
+	if d.IsDecrementSize == nil || *d.IsDecrementSize {
+		size--
+	}
+	b.resize(p, size)
+	if d.IsAutoTerminate != nil && *d.IsAutoTerminate {
+		b.terminate(b.instances[instanceID], false)
+	}
+	return core.DetachInstancePoolInstanceResponse{}, nil
+}
@@ -0,0 +1,220 @@
+package ocifake
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// createPool creates an instance configuration and a pool of size members
+// spread across ads
+func createPool(t *testing.T, b *Backend, size int, ads ...string) core.InstancePool {
+	t.Helper()
+	ctx := context.Background()
+	compartment := common.String("ocid1.compartment.oc1..test")
+	cfg, err := b.CreateInstanceConfiguration(ctx, core.CreateInstanceConfigurationRequest{
+		CreateInstanceConfiguration: core.CreateInstanceConfigurationDetails{
+			CompartmentId: compartment,
+			InstanceDetails: core.ComputeInstanceDetails{LaunchDetails: &core.InstanceConfigurationLaunchInstanceDetails{
+				CompartmentId: compartment,
+				Shape:         common.String("VM.Standard2.1"),
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateInstanceConfiguration: %v", err)
+	}
+	var placements []core.CreateInstancePoolPlacementConfigurationDetails
+	for _, ad := range ads {
+		placements = append(placements, core.CreateInstancePoolPlacementConfigurationDetails{
+			AvailabilityDomain: common.String(ad),
+			PrimarySubnetId:    common.String("ocid1.subnet.oc1..test"),
+		})
+	}
+	resp, err := b.CreateInstancePool(ctx, core.CreateInstancePoolRequest{CreateInstancePoolDetails: core.CreateInstancePoolDetails{
+		CompartmentId:           compartment,
+		InstanceConfigurationId: cfg.Id,
+		PlacementConfigurations: placements,
+		Size:                    common.Int(size),
+		DisplayName:             common.String("web"),
+	}})
+	if err != nil {
+		t.Fatalf("CreateInstancePool: %v", err)
+	}
+	return resp.InstancePool
+}
+
+func poolState(t *testing.T, b *Backend, id *string) core.InstancePool {
+	t.Helper()
+	resp, err := b.GetInstancePool(context.Background(), core.GetInstancePoolRequest{InstancePoolId: id})
+	if err != nil {
+		t.Fatalf("GetInstancePool: %v", err)
+	}
+	return resp.InstancePool
+}
+
+func TestPoolProvisionsThenRuns(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(WithClock(clock.Now), WithTimeline(Timeline{Provisioning: time.Minute}))
+
+	p := createPool(t, b, 4, "AD-1", "AD-2")
+	if p.LifecycleState != core.InstancePoolLifecycleStateProvisioning {
+		t.Fatalf("state after create = %s, want PROVISIONING", p.LifecycleState)
+	}
+	perAD := map[string]int{}
+	for _, inst := range b.Instances() {
+		perAD[*inst.AvailabilityDomain]++
+	}
+	if perAD["AD-1"] != 2 || perAD["AD-2"] != 2 {
+		t.Errorf("members per AD = %v, want 2 in each", perAD)
+	}
+
+	_, err := b.UpdateInstancePool(context.Background(), core.UpdateInstancePoolRequest{
+		InstancePoolId:            p.Id,
+		UpdateInstancePoolDetails: core.UpdateInstancePoolDetails{Size: common.Int(6)},
+	})
+	var serr ServiceError
+	if !errors.As(err, &serr) || serr.StatusCode != 409 {
+		t.Fatalf("scaling a provisioning pool: err = %v, want a 409", err)
+	}
+
+	clock.Advance(time.Minute)
+	if got := poolState(t, b, p.Id).LifecycleState; got != core.InstancePoolLifecycleStateRunning {
+		t.Fatalf("state after provisioning = %s, want RUNNING", got)
+	}
+}
+
+func TestPoolScalesUpAndDown(t *testing.T) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New(WithClock(clock.Now), WithTimeline(Timeline{Provisioning: time.Minute}))
+	p := createPool(t, b, 2, "AD-1")
+	clock.Advance(time.Minute)
+	ctx := context.Background()
+
+	resp, err := b.UpdateInstancePool(ctx, core.UpdateInstancePoolRequest{
+		InstancePoolId:            p.Id,
+		UpdateInstancePoolDetails: core.UpdateInstancePoolDetails{Size: common.Int(5)},
+	})
+	if err != nil {
+		t.Fatalf("scale up: %v", err)
+	}
+	if *resp.Size != 5 || resp.LifecycleState != core.InstancePoolLifecycleStateScaling {
+		t.Fatalf("after scale up: size %d state %s, want 5 SCALING", *resp.Size, resp.LifecycleState)
+	}
+	clock.Advance(time.Minute)
+
+	if _, err := b.UpdateInstancePool(ctx, core.UpdateInstancePoolRequest{
+		InstancePoolId:            p.Id,
+		UpdateInstancePoolDetails: core.UpdateInstancePoolDetails{Size: common.Int(1)},
+	}); err != nil {
+		t.Fatalf("scale down: %v", err)
+	}
+	live := 0
+	for _, inst := range b.Instances() {
+		if inst.LifecycleState == core.InstanceLifecycleStateRunning {
+			live++
+		}
+	}
+	if got := *poolState(t, b, p.Id).Size; got != 1 || live != 1 {
+		t.Errorf("after scale down: size %d with %d running instances, want 1 and 1", got, live)
+	}
+}
+
+func TestDetachInstancePoolInstance(t *testing.T) {
+	b := New()
+	p := createPool(t, b, 3, "AD-1")
+	ctx := context.Background()
+	members := b.Instances()
+
+	if _, err := b.DetachInstancePoolInstance(ctx, core.DetachInstancePoolInstanceRequest{
+		InstancePoolId: p.Id,
+		DetachInstancePoolInstanceDetails: core.DetachInstancePoolInstanceDetails{
+			InstanceId:      members[0].Id,
+			IsDecrementSize: common.Bool(true),
+			IsAutoTerminate: common.Bool(true),
+		},
+	}); err != nil {
+		t.Fatalf("detach with decrement: %v", err)
+	}
+	if got := *poolState(t, b, p.Id).Size; got != 2 {
+		t.Errorf("size after a decrementing detach = %d, want 2", got)
+	}
+	if got := getState(t, b, *members[0].Id); got != core.InstanceLifecycleStateTerminated {
+		t.Errorf("auto-terminated instance is %s, want TERMINATED", got)
+	}
+
+	if _, err := b.DetachInstancePoolInstance(ctx, core.DetachInstancePoolInstanceRequest{
+		InstancePoolId: p.Id,
+		DetachInstancePoolInstanceDetails: core.DetachInstancePoolInstanceDetails{
+			InstanceId:      members[1].Id,
+			IsDecrementSize: common.Bool(false),
+		},
+	}); err != nil {
+		t.Fatalf("detach with replacement: %v", err)
+	}
+	if got := *poolState(t, b, p.Id).Size; got != 2 {
+		t.Errorf("size after a replacing detach = %d, want 2", got)
+	}
+	if got := getState(t, b, *members[1].Id); got != core.InstanceLifecycleStateRunning {
+		t.Errorf("detached instance is %s, want it left RUNNING", got)
+	}
+	if got := len(b.Instances()); got != 4 {
+		t.Errorf("%d instances, want 4 (3 launched with the pool and 1 replacement)", got)
+	}
+}
+
+func TestListInstancePoolInstancesPages(t *testing.T) {
+	b := New()
+	p := createPool(t, b, 2*poolInstancesPageSize+1, "AD-1")
+	ctx := context.Background()
+
+	seen := map[string]bool{}
+	pages := 0
+	var page *string
+	for {
+		resp, err := b.ListInstancePoolInstances(ctx, core.ListInstancePoolInstancesRequest{InstancePoolId: p.Id, Page: page})
+		if err != nil {
+			t.Fatalf("ListInstancePoolInstances: %v", err)
+		}
+		pages++
+		for _, m := range resp.Items {
+			if *m.State != "Running" {
+				t.Errorf("member %s state = %q, want Running", *m.Id, *m.State)
+			}
+			seen[*m.Id] = true
+		}
+		if resp.OpcNextPage == nil {
+			break
+		}
+		page = resp.OpcNextPage
+	}
+	if pages != 3 || len(seen) != 2*poolInstancesPageSize+1 {
+		t.Errorf("listed %d members over %d pages, want %d over 3", len(seen), pages, 2*poolInstancesPageSize+1)
+	}
+}
+
+func TestDeleteInstanceConfigurationInUse(t *testing.T) {
+	b := New()
+	p := createPool(t, b, 1, "AD-1")
+	ctx := context.Background()
+
+	_, err := b.DeleteInstanceConfiguration(ctx, core.DeleteInstanceConfigurationRequest{InstanceConfigurationId: p.InstanceConfigurationId})
+	var serr ServiceError
+	if !errors.As(err, &serr) || serr.StatusCode != 409 {
+		t.Fatalf("deleting a configuration in use: err = %v, want a 409", err)
+	}
+
+	if _, err := b.TerminateInstancePool(ctx, core.TerminateInstancePoolRequest{InstancePoolId: p.Id}); err != nil {
+		t.Fatalf("TerminateInstancePool: %v", err)
+	}
+	if _, err := b.DeleteInstanceConfiguration(ctx, core.DeleteInstanceConfigurationRequest{InstanceConfigurationId: p.InstanceConfigurationId}); err != nil {
+		t.Fatalf("deleting after the pool terminated: %v", err)
+	}
+	if got := len(b.InstanceConfigurations()); got != 0 {
+		t.Errorf("%d configurations left, want 0", got)
+	}
+}
@@ -0,0 +1,135 @@
+package ocifake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Duration is a time.Duration that reads from JSON strings such as "30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a Go duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a Go duration string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// TimelineSpec is the JSON form of a Timeline
+type TimelineSpec struct {
+	Provisioning Duration `json:"provisioning"`
+	Terminating  Duration `json:"terminating"`
+}
+
+// Timeline converts the spec to a Timeline
+func (t TimelineSpec) Timeline() Timeline {
+	return Timeline{Provisioning: time.Duration(t.Provisioning), Terminating: time.Duration(t.Terminating)}
+}
+
+// ScenarioStep changes the backend's behaviour once After has elapsed since
+// the scenario started, e.g. to throttle launches only during a burst
+type ScenarioStep struct {
+	After       Duration      `json:"after"`
+	Timeline    *TimelineSpec `json:"timeline,omitempty"`
+	Latency     *Duration     `json:"latency,omitempty"`
+	ClearFaults bool          `json:"clear_faults,omitempty"`
+	Faults      []FaultSpec   `json:"faults,omitempty"`
+	Capacity    []Capacity    `json:"capacity,omitempty"`
+}
+
+// Scenario is a scripted timeline of lifecycle durations, latency and faults,
+// loaded from a JSON file by the ocifake server
+type Scenario struct {
+	Timeline TimelineSpec   `json:"timeline"`
+	Latency  Duration       `json:"latency"`
+	Faults   []FaultSpec    `json:"faults,omitempty"`
+	Capacity []Capacity     `json:"capacity,omitempty"`
+	Steps    []ScenarioStep `json:"steps,omitempty"`
+
+	Compartments []Compartment `json:"compartments,omitempty"`
+}
+
+// LoadScenario reads a scenario from a JSON file
+func LoadScenario(filename string) (*Scenario, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario file: %w", err)
+	}
+	return &sc, nil
+}
+
+// Apply sets the initial timeline, latency, faults, capacity and compartments
+// on b
+func (sc *Scenario) Apply(b *Backend) error {
+	b.SetTimeline(sc.Timeline.Timeline())
+	b.SetLatency(time.Duration(sc.Latency))
+	for _, c := range sc.Compartments {
+		b.AddCompartment(c)
+	}
+	for _, c := range sc.Capacity {
+		b.SetCapacity(c)
+	}
+	for _, f := range sc.Faults {
+		if err := b.Inject(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run applies each step at its offset from now until all steps have run or
+// ctx is cancelled. Errors from invalid fault specs are returned immediately.
+func (sc *Scenario) Run(ctx context.Context, b *Backend) error {
+	steps := append([]ScenarioStep(nil), sc.Steps...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].After < steps[j].After })
+
+	start := time.Now()
+	for _, step := range steps {
+		timer := time.NewTimer(time.Until(start.Add(time.Duration(step.After))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if step.ClearFaults {
+			b.ClearFaults()
+		}
+		if step.Timeline != nil {
+			b.SetTimeline(step.Timeline.Timeline())
+		}
+		if step.Latency != nil {
+			b.SetLatency(time.Duration(*step.Latency))
+		}
+		for _, c := range step.Capacity {
+			b.SetCapacity(c)
+		}
+		for _, f := range step.Faults {
+			if err := b.Inject(f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
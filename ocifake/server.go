@@ -0,0 +1,494 @@
+package ocifake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+// apiVersion is the path prefix the SDK uses for Compute, ComputeManagement,
+// Block Storage and Identity
+const apiVersion = "/20160918"
+
+// adminPrefix is the path prefix for the control endpoints used by test
+// scripts to inject faults and inspect state. It is not part of the OCI API.
+const adminPrefix = "/_fake"
+
+// Server exposes a Backend over HTTP using the same REST paths and JSON
+// bodies as the OCI Compute, ComputeManagement, Block Storage and Identity
+// services, so the SDK clients can be pointed at it by overriding their Host.
+// Request signatures are not checked.
+type Server struct {
+	Backend *Backend
+	Logger  *log.Logger // optional request log
+
+	requests uint64
+}
+
+// NewServer returns a Server for backend
+func NewServer(backend *Backend) *Server {
+	return &Server{Backend: backend}
+}
+
+// ServeHTTP routes a request to the matching API operation
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Logger != nil {
+		s.Logger.Printf("%s %s", r.Method, r.URL.Path)
+	}
+	w.Header().Set("opc-request-id", fmt.Sprintf("fake-%08d", atomic.AddUint64(&s.requests, 1)))
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, adminPrefix+"/"):
+		s.serveAdmin(w, r, strings.Split(strings.TrimPrefix(r.URL.Path, adminPrefix+"/"), "/"))
+	case strings.HasPrefix(r.URL.Path, apiVersion+"/"):
+		s.serveAPI(w, r, strings.Split(strings.TrimPrefix(r.URL.Path, apiVersion+"/"), "/"))
+	default:
+		writeError(w, NotFound(r.URL.Path))
+	}
+}
+
+// serveAPI dispatches OCI API calls. parts is the path split on "/" after the
+// API version prefix.
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, parts []string) {
+	ctx := r.Context()
+	route := r.Method + " " + parts[0]
+	if len(parts) > 1 {
+		route += "/{id}"
+	}
+	if len(parts) > 2 {
+		route += "/" + strings.Join(parts[2:], "/")
+	}
+
+	var id string
+	if len(parts) > 1 {
+		id = parts[1]
+	}
+
+	switch route {
+	case "POST instances":
+		var details core.LaunchInstanceDetails
+		if !decodeBody(w, r, &details) {
+			return
+		}
+		resp, err := s.Backend.LaunchInstance(ctx, core.LaunchInstanceRequest{
+			LaunchInstanceDetails: details,
+			OpcRetryToken:         retryToken(r),
+		})
+		writeResult(w, http.StatusOK, resp.Instance, err)
+
+	case "GET instances":
+		q := r.URL.Query()
+		request := core.ListInstancesRequest{
+			CompartmentId:  common.String(q.Get("compartmentId")),
+			LifecycleState: core.InstanceLifecycleStateEnum(q.Get("lifecycleState")),
+		}
+		if v := q.Get("availabilityDomain"); v != "" {
+			request.AvailabilityDomain = common.String(v)
+		}
+		if v := q.Get("displayName"); v != "" {
+			request.DisplayName = common.String(v)
+		}
+		if v := q.Get("page"); v != "" {
+			request.Page = common.String(v)
+		}
+		if v := q.Get("limit"); v != "" {
+			var limit int
+			fmt.Sscan(v, &limit)
+			request.Limit = common.Int(limit)
+		}
+		resp, err := s.Backend.ListInstances(ctx, request)
+		if resp.OpcNextPage != nil {
+			w.Header().Set("opc-next-page", *resp.OpcNextPage)
+		}
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "GET instances/{id}":
+		resp, err := s.Backend.GetInstance(ctx, core.GetInstanceRequest{InstanceId: common.String(id)})
+		writeResult(w, http.StatusOK, resp.Instance, err)
+
+	case "DELETE instances/{id}":
+		_, err := s.Backend.TerminateInstance(ctx, core.TerminateInstanceRequest{
+			InstanceId:         common.String(id),
+			PreserveBootVolume: common.Bool(r.URL.Query().Get("preserveBootVolume") == "true"),
+		})
+		writeResult(w, http.StatusNoContent, nil, err)
+
+	case "POST instanceConfigurations":
+		var details core.CreateInstanceConfigurationDetails
+		if !decodeBody(w, r, &details) {
+			return
+		}
+		resp, err := s.Backend.CreateInstanceConfiguration(ctx, core.CreateInstanceConfigurationRequest{
+			CreateInstanceConfiguration: details,
+			OpcRetryToken:               retryToken(r),
+		})
+		writeResult(w, http.StatusOK, resp.InstanceConfiguration, err)
+
+	case "GET instanceConfigurations":
+		resp, err := s.Backend.ListInstanceConfigurations(ctx, core.ListInstanceConfigurationsRequest{
+			CompartmentId: common.String(r.URL.Query().Get("compartmentId")),
+		})
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "DELETE instanceConfigurations/{id}":
+		_, err := s.Backend.DeleteInstanceConfiguration(ctx, core.DeleteInstanceConfigurationRequest{InstanceConfigurationId: common.String(id)})
+		writeResult(w, http.StatusNoContent, nil, err)
+
+	case "POST instancePools":
+		var details core.CreateInstancePoolDetails
+		if !decodeBody(w, r, &details) {
+			return
+		}
+		resp, err := s.Backend.CreateInstancePool(ctx, core.CreateInstancePoolRequest{
+			CreateInstancePoolDetails: details,
+			OpcRetryToken:             retryToken(r),
+		})
+		writeResult(w, http.StatusOK, resp.InstancePool, err)
+
+	case "GET instancePools":
+		q := r.URL.Query()
+		resp, err := s.Backend.ListInstancePools(ctx, core.ListInstancePoolsRequest{
+			CompartmentId:  common.String(q.Get("compartmentId")),
+			LifecycleState: core.InstancePoolSummaryLifecycleStateEnum(q.Get("lifecycleState")),
+		})
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "GET instancePools/{id}":
+		resp, err := s.Backend.GetInstancePool(ctx, core.GetInstancePoolRequest{InstancePoolId: common.String(id)})
+		writeResult(w, http.StatusOK, resp.InstancePool, err)
+
+	case "PUT instancePools/{id}":
+		var details core.UpdateInstancePoolDetails
+		if !decodeBody(w, r, &details) {
+			return
+		}
+		resp, err := s.Backend.UpdateInstancePool(ctx, core.UpdateInstancePoolRequest{
+			InstancePoolId:            common.String(id),
+			UpdateInstancePoolDetails: details,
+		})
+		writeResult(w, http.StatusOK, resp.InstancePool, err)
+
+	case "DELETE instancePools/{id}":
+		_, err := s.Backend.TerminateInstancePool(ctx, core.TerminateInstancePoolRequest{InstancePoolId: common.String(id)})
+		writeResult(w, http.StatusNoContent, nil, err)
+
+	case "GET instancePools/{id}/instances":
+		q := r.URL.Query()
+		request := core.ListInstancePoolInstancesRequest{
+			CompartmentId:  common.String(q.Get("compartmentId")),
+			InstancePoolId: common.String(id),
+		}
+		if v := q.Get("page"); v != "" {
+			request.Page = common.String(v)
+		}
+		if v := q.Get("limit"); v != "" {
+			var limit int
+			fmt.Sscan(v, &limit)
+			request.Limit = common.Int(limit)
+		}
+		resp, err := s.Backend.ListInstancePoolInstances(ctx, request)
+		if resp.OpcNextPage != nil {
+			w.Header().Set("opc-next-page", *resp.OpcNextPage)
+		}
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "POST instancePools/{id}/actions/detachInstance":
+		var details core.DetachInstancePoolInstanceDetails
+		if !decodeBody(w, r, &details) {
+			return
+		}
+		_, err := s.Backend.DetachInstancePoolInstance(ctx, core.DetachInstancePoolInstanceRequest{
+			InstancePoolId:                    common.String(id),
+			DetachInstancePoolInstanceDetails: details,
+		})
+		writeResult(w, http.StatusOK, nil, err)
+
+	case "GET bootVolumes":
+		q := r.URL.Query()
+		request := core.ListBootVolumesRequest{CompartmentId: common.String(q.Get("compartmentId"))}
+		if v := q.Get("availabilityDomain"); v != "" {
+			request.AvailabilityDomain = common.String(v)
+		}
+		resp, err := s.Backend.ListBootVolumes(ctx, request)
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "DELETE bootVolumes/{id}":
+		_, err := s.Backend.DeleteBootVolume(ctx, core.DeleteBootVolumeRequest{BootVolumeId: common.String(id)})
+		writeResult(w, http.StatusNoContent, nil, err)
+
+	case "GET volumes":
+		q := r.URL.Query()
+		request := core.ListVolumesRequest{CompartmentId: common.String(q.Get("compartmentId"))}
+		if v := q.Get("availabilityDomain"); v != "" {
+			request.AvailabilityDomain = common.String(v)
+		}
+		resp, err := s.Backend.ListVolumes(ctx, request)
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "DELETE volumes/{id}":
+		_, err := s.Backend.DeleteVolume(ctx, core.DeleteVolumeRequest{VolumeId: common.String(id)})
+		writeResult(w, http.StatusNoContent, nil, err)
+
+	case "GET volumeAttachments":
+		q := r.URL.Query()
+		request := core.ListVolumeAttachmentsRequest{CompartmentId: common.String(q.Get("compartmentId"))}
+		if v := q.Get("availabilityDomain"); v != "" {
+			request.AvailabilityDomain = common.String(v)
+		}
+		if v := q.Get("instanceId"); v != "" {
+			request.InstanceId = common.String(v)
+		}
+		if v := q.Get("volumeId"); v != "" {
+			request.VolumeId = common.String(v)
+		}
+		resp, err := s.Backend.ListVolumeAttachments(ctx, request)
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "GET bootVolumeAttachments":
+		q := r.URL.Query()
+		request := core.ListBootVolumeAttachmentsRequest{
+			AvailabilityDomain: common.String(q.Get("availabilityDomain")),
+			CompartmentId:      common.String(q.Get("compartmentId")),
+		}
+		if v := q.Get("instanceId"); v != "" {
+			request.InstanceId = common.String(v)
+		}
+		if v := q.Get("bootVolumeId"); v != "" {
+			request.BootVolumeId = common.String(v)
+		}
+		resp, err := s.Backend.ListBootVolumeAttachments(ctx, request)
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	case "GET compartments":
+		q := r.URL.Query()
+		resp, err := s.Backend.ListCompartments(ctx, identity.ListCompartmentsRequest{
+			CompartmentId:          common.String(q.Get("compartmentId")),
+			CompartmentIdInSubtree: common.Bool(q.Get("compartmentIdInSubtree") == "true"),
+		})
+		writeResult(w, http.StatusOK, resp.Items, err)
+
+	default:
+		writeError(w, ServiceError{StatusCode: http.StatusNotFound, Code: "NotFound", Message: fmt.Sprintf("%s is not implemented by the fake", route)})
+	}
+}
+
+// retryToken returns the request's opc-retry-token header, if it has one
+func retryToken(r *http.Request) *string {
+	if token := r.Header.Get("opc-retry-token"); token != "" {
+		return common.String(token)
+	}
+	return nil
+}
+
+// FaultSpec describes an injected error in a scenario file or admin request.
+// Error names one of the canned errors (TooManyRequests, InternalError,
+// ServiceUnavailable, OutOfCapacity, LimitExceeded); alternatively Status,
+// Code and Message describe a custom service error. LostResponse lets the
+// call take effect and returns the error in place of its response.
+type FaultSpec struct {
+	Op           string `json:"op"`
+	Error        string `json:"error,omitempty"`
+	Status       int    `json:"status,omitempty"`
+	Code         string `json:"code,omitempty"`
+	Message      string `json:"message,omitempty"`
+	Times        int    `json:"times,omitempty"`
+	LostResponse bool   `json:"lost_response,omitempty"`
+}
+
+// ServiceError converts the spec into the error to inject
+func (f FaultSpec) ServiceError() (ServiceError, error) {
+	switch f.Error {
+	case "":
+		if f.Status == 0 || f.Code == "" {
+			return ServiceError{}, fmt.Errorf("fault for %s needs either error or status and code", f.Op)
+		}
+		return ServiceError{StatusCode: f.Status, Code: f.Code, Message: f.Message}, nil
+	case "TooManyRequests":
+		return TooManyRequests(), nil
+	case "InternalError":
+		return InternalError(), nil
+	case "ServiceUnavailable":
+		return ServiceUnavailable(), nil
+	case "OutOfCapacity":
+		return OutOfCapacity(), nil
+	case "LimitExceeded":
+		return LimitExceeded(), nil
+	default:
+		return ServiceError{}, fmt.Errorf("unknown error %q for %s", f.Error, f.Op)
+	}
+}
+
+// Inject adds the fault described by spec to the backend
+func (b *Backend) Inject(spec FaultSpec) error {
+	if spec.Op == "" {
+		return fmt.Errorf("fault is missing op")
+	}
+	err, convErr := spec.ServiceError()
+	if convErr != nil {
+		return convErr
+	}
+	if spec.LostResponse {
+		b.InjectLostResponse(spec.Op, err, spec.Times)
+		return nil
+	}
+	b.InjectError(spec.Op, err, spec.Times)
+	return nil
+}
+
+// State is the snapshot returned by the admin state endpoint
+type State struct {
+	Instances              []core.Instance              `json:"instances"`
+	InstanceConfigurations []core.InstanceConfiguration `json:"instanceConfigurations"`
+	InstancePools          []core.InstancePool          `json:"instancePools"`
+	BootVolumes            []core.BootVolume            `json:"bootVolumes"`
+	Volumes                []core.Volume                `json:"volumes"`
+}
+
+// serveAdmin handles the non-OCI control endpoints:
+//
+//	POST   /_fake/faults                 inject a FaultSpec
+//	DELETE /_fake/faults                 clear all faults
+//	PUT    /_fake/timeline               replace the Timeline
+//	PUT    /_fake/capacity               add or replace a Capacity rule
+//	DELETE /_fake/capacity               remove all capacity rules
+//	PUT    /_fake/compartments           add a Compartment
+//	POST   /_fake/instances/{id}/state   pin an instance to {"state": "..."}
+//	POST   /_fake/volumes                add a BlockVolume, returning the volume
+//	GET    /_fake/state                  dump instances, configurations, pools, boot and block volumes
+func (s *Server) serveAdmin(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "faults":
+		var spec FaultSpec
+		if !decodeBody(w, r, &spec) {
+			return
+		}
+		if err := s.Backend.Inject(spec); err != nil {
+			writeError(w, ServiceError{StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodDelete && len(parts) == 1 && parts[0] == "faults":
+		s.Backend.ClearFaults()
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut && len(parts) == 1 && parts[0] == "timeline":
+		var t TimelineSpec
+		if !decodeBody(w, r, &t) {
+			return
+		}
+		s.Backend.SetTimeline(t.Timeline())
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut && len(parts) == 1 && parts[0] == "capacity":
+		var c Capacity
+		if !decodeBody(w, r, &c) {
+			return
+		}
+		s.Backend.SetCapacity(c)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodDelete && len(parts) == 1 && parts[0] == "capacity":
+		s.Backend.ClearCapacity()
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut && len(parts) == 1 && parts[0] == "compartments":
+		var c Compartment
+		if !decodeBody(w, r, &c) {
+			return
+		}
+		if c.ID == "" || c.Parent == "" {
+			writeError(w, ServiceError{StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: "compartment needs id and parent"})
+			return
+		}
+		s.Backend.AddCompartment(c)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPost && len(parts) == 3 && parts[0] == "instances" && parts[2] == "state":
+		var body struct {
+			State string `json:"state"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		err := s.Backend.SetInstanceState(parts[1], core.InstanceLifecycleStateEnum(body.State))
+		writeResult(w, http.StatusNoContent, nil, err)
+
+	case r.Method == http.MethodPost && len(parts) == 1 && parts[0] == "volumes":
+		var spec BlockVolume
+		if !decodeBody(w, r, &spec) {
+			return
+		}
+		v, err := s.Backend.AddVolume(spec)
+		writeResult(w, http.StatusOK, v, err)
+
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "state":
+		writeJSON(w, http.StatusOK, State{
+			Instances:              s.Backend.Instances(),
+			InstanceConfigurations: s.Backend.InstanceConfigurations(),
+			InstancePools:          s.Backend.Pools(),
+			BootVolumes:            s.Backend.BootVolumes(),
+			Volumes:                s.Backend.Volumes(),
+		})
+
+	default:
+		writeError(w, NotFound(r.URL.Path))
+	}
+}
+
+// decodeBody parses the JSON request body into v, writing a 400 on failure
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	data, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		writeError(w, ServiceError{StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: fmt.Sprintf("invalid request body: %v", err)})
+		return false
+	}
+	return true
+}
+
+// writeResult writes body with status, or the error if err is not nil
+func writeResult(w http.ResponseWriter, status int, body interface{}, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	writeJSON(w, status, body)
+}
+
+// writeError writes err in the OCI error body format
+func writeError(w http.ResponseWriter, err error) {
+	var se ServiceError
+	switch {
+	case errors.As(err, &se):
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		se = ServiceError{StatusCode: http.StatusRequestTimeout, Code: "RequestTimeout", Message: err.Error()}
+	default:
+		se = ServiceError{StatusCode: http.StatusInternalServerError, Code: "InternalError", Message: err.Error()}
+	}
+	writeJSON(w, se.StatusCode, map[string]string{"code": se.Code, "message": se.Message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("ocifake: failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package ocifake
+
+import (
+	"context"
+	"sort"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// bootVolumeSizeGBs is the size of every simulated boot volume, the service
+// default for platform images
+const bootVolumeSizeGBs = 47
+
+// bootVolume is the simulated state of an instance's boot volume and its
+// attachment
+type bootVolume struct {
+	details    core.BootVolume
+	attachment core.BootVolumeAttachment
+}
+
+// createBootVolume creates the boot volume for a newly launched instance,
+// attached to it and named after it. Like the service, it does not copy the
+// instance's tags to the volume. Callers must hold b.mu.
+func (b *Backend) createBootVolume(inst *instance) {
+	d := inst.details
+	size := int64(bootVolumeSizeGBs)
+	bv := &bootVolume{
+		details: core.BootVolume{
+			Id:                 common.String(b.nextID("bootvolume")),
+			AvailabilityDomain: d.AvailabilityDomain,
+			CompartmentId:      d.CompartmentId,
+			DisplayName:        common.String(derefString(d.DisplayName) + " (Boot Volume)"),
+			ImageId:            d.ImageId,
+			SizeInGBs:          &size,
+			SizeInMBs:          common.Int64(size * 1024),
+			LifecycleState:     core.BootVolumeLifecycleStateAvailable,
+			TimeCreated:        d.TimeCreated,
+		},
+	}
+	bv.attachment = core.BootVolumeAttachment{
+		Id:                 common.String(b.nextID("bootvolumeattachment")),
+		AvailabilityDomain: d.AvailabilityDomain,
+		CompartmentId:      d.CompartmentId,
+		BootVolumeId:       bv.details.Id,
+		InstanceId:         d.Id,
+		LifecycleState:     core.BootVolumeAttachmentLifecycleStateAttached,
+		TimeCreated:        d.TimeCreated,
+	}
+	inst.bootVolumeID = *bv.details.Id
+	b.bootVolumes[*bv.details.Id] = bv
+}
+
+// releaseBootVolume detaches a terminated instance's boot volume, deleting it
+// unless preserve is set. Callers must hold b.mu.
+func (b *Backend) releaseBootVolume(inst *instance, preserve bool) {
+	bv, ok := b.bootVolumes[inst.bootVolumeID]
+	if !ok || bv.attachment.LifecycleState == core.BootVolumeAttachmentLifecycleStateDetached {
+		return
+	}
+	bv.attachment.LifecycleState = core.BootVolumeAttachmentLifecycleStateDetached
+	if !preserve {
+		bv.details.LifecycleState = core.BootVolumeLifecycleStateTerminated
+	}
+}
+
+// BootVolumes returns a snapshot of every boot volume the backend knows
+// about, ordered by creation
+func (b *Backend) BootVolumes() []core.BootVolume {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]core.BootVolume, 0, len(b.bootVolumes))
+	for _, bv := range b.bootVolumes {
+		out = append(out, bv.details)
+	}
+	sort.Slice(out, func(i, j int) bool { return *out[i].Id < *out[j].Id })
+	return out
+}
+
+// ListBootVolumes simulates core.BlockstorageClient.ListBootVolumes,
+// filtering on compartment and availability domain
+func (b *Backend) ListBootVolumes(ctx context.Context, request core.ListBootVolumesRequest) (core.ListBootVolumesResponse, error) {
+	if err := b.begin(ctx, OpListBootVolumes); err != nil {
+		return core.ListBootVolumesResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := []core.BootVolume{} // the SDK cannot unmarshal a null list
+	for _, bv := range b.bootVolumes {
+		d := bv.details
+		switch {
+		case derefString(request.CompartmentId) != derefString(d.CompartmentId):
+		case request.AvailabilityDomain != nil && *request.AvailabilityDomain != derefString(d.AvailabilityDomain):
+		default:
+			items = append(items, d)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+	return core.ListBootVolumesResponse{Items: items}, nil
+}
+
+// ListBootVolumeAttachments simulates
+// core.ComputeClient.ListBootVolumeAttachments, including detached
+// attachments as the service does for a while after an instance terminates
+func (b *Backend) ListBootVolumeAttachments(ctx context.Context, request core.ListBootVolumeAttachmentsRequest) (core.ListBootVolumeAttachmentsResponse, error) {
+	if err := b.begin(ctx, OpListBootVolumeAttachments); err != nil {
+		return core.ListBootVolumeAttachmentsResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := []core.BootVolumeAttachment{} // the SDK cannot unmarshal a null list
+	for _, bv := range b.bootVolumes {
+		a := bv.attachment
+		switch {
+		case derefString(request.CompartmentId) != derefString(a.CompartmentId):
+		case derefString(request.AvailabilityDomain) != derefString(a.AvailabilityDomain):
+		case request.InstanceId != nil && *request.InstanceId != derefString(a.InstanceId):
+		case request.BootVolumeId != nil && *request.BootVolumeId != derefString(a.BootVolumeId):
+		default:
+			items = append(items, a)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+	return core.ListBootVolumeAttachmentsResponse{Items: items}, nil
+}
+
+// DeleteBootVolume simulates core.BlockstorageClient.DeleteBootVolume. A
+// boot volume still attached to an instance cannot be deleted.
+func (b *Backend) DeleteBootVolume(ctx context.Context, request core.DeleteBootVolumeRequest) (core.DeleteBootVolumeResponse, error) {
+	if err := b.begin(ctx, OpDeleteBootVolume); err != nil {
+		return core.DeleteBootVolumeResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	bv, ok := b.bootVolumes[derefString(request.BootVolumeId)]
+	if !ok {
+		return core.DeleteBootVolumeResponse{}, NotFound(derefString(request.BootVolumeId))
+	}
+	if bv.attachment.LifecycleState != core.BootVolumeAttachmentLifecycleStateDetached {
+		return core.DeleteBootVolumeResponse{}, Conflict("Boot volume " + *bv.details.Id + " is attached to instance " + derefString(bv.attachment.InstanceId))
+	}
+	bv.details.LifecycleState = core.BootVolumeLifecycleStateTerminated
+	return core.DeleteBootVolumeResponse{}, nil
+}
+
+// BlockVolume is a block volume added through the control endpoint. The fake
+// never creates block volumes itself, since the tool does not; they stand for
+// volumes a user attached to the tool's instances.
+type BlockVolume struct {
+	DisplayName        string            `json:"displayName"`
+	CompartmentID      string            `json:"compartmentId,omitempty"`      // defaults to the instance's
+	AvailabilityDomain string            `json:"availabilityDomain,omitempty"` // defaults to the instance's
+	SizeInGBs          int64             `json:"sizeInGBs,omitempty"`          // defaults to 50
+	FreeformTags       map[string]string `json:"freeformTags,omitempty"`
+	InstanceID         string            `json:"instanceId,omitempty"` // attach the volume to this instance
+}
+
+// blockVolume is the simulated state of a block volume and its attachment,
+// if it has one
+type blockVolume struct {
+	details    core.Volume
+	attachment *core.ParavirtualizedVolumeAttachment
+}
+
+// AddVolume creates a block volume, attached to spec.InstanceID if one is
+// given, and returns it
+func (b *Backend) AddVolume(spec BlockVolume) (core.Volume, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var inst *instance
+	if spec.InstanceID != "" {
+		var ok bool
+		if inst, ok = b.instances[spec.InstanceID]; !ok {
+			return core.Volume{}, NotFound(spec.InstanceID)
+		}
+		if spec.CompartmentID == "" {
+			spec.CompartmentID = derefString(inst.details.CompartmentId)
+		}
+		if spec.AvailabilityDomain == "" {
+			spec.AvailabilityDomain = derefString(inst.details.AvailabilityDomain)
+		}
+	}
+	if spec.SizeInGBs == 0 {
+		spec.SizeInGBs = 50
+	}
+	now := &common.SDKTime{Time: b.now()}
+	v := &blockVolume{
+		details: core.Volume{
+			Id:                 common.String(b.nextID("volume")),
+			AvailabilityDomain: common.String(spec.AvailabilityDomain),
+			CompartmentId:      common.String(spec.CompartmentID),
+			DisplayName:        common.String(spec.DisplayName),
+			SizeInGBs:          common.Int64(spec.SizeInGBs),
+			SizeInMBs:          common.Int64(spec.SizeInGBs * 1024),
+			LifecycleState:     core.VolumeLifecycleStateAvailable,
+			TimeCreated:        now,
+			FreeformTags:       spec.FreeformTags,
+		},
+	}
+	if inst != nil {
+		v.attachment = &core.ParavirtualizedVolumeAttachment{
+			Id:                 common.String(b.nextID("volumeattachment")),
+			AvailabilityDomain: v.details.AvailabilityDomain,
+			CompartmentId:      v.details.CompartmentId,
+			InstanceId:         inst.details.Id,
+			VolumeId:           v.details.Id,
+			LifecycleState:     core.VolumeAttachmentLifecycleStateAttached,
+			TimeCreated:        now,
+		}
+	}
+	b.volumes[*v.details.Id] = v
+	return v.details, nil
+}
+
+// detachVolumes detaches a terminated instance's block volumes, which
+// outlive it as they do in the service. Callers must hold b.mu.
+func (b *Backend) detachVolumes(inst *instance) {
+	for _, v := range b.volumes {
+		if v.attachment != nil && derefString(v.attachment.InstanceId) == derefString(inst.details.Id) {
+			v.attachment.LifecycleState = core.VolumeAttachmentLifecycleStateDetached
+		}
+	}
+}
+
+// Volumes returns a snapshot of every block volume the backend knows about,
+// ordered by creation
+func (b *Backend) Volumes() []core.Volume {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]core.Volume, 0, len(b.volumes))
+	for _, v := range b.volumes {
+		out = append(out, v.details)
+	}
+	sort.Slice(out, func(i, j int) bool { return *out[i].Id < *out[j].Id })
+	return out
+}
+
+// ListVolumes simulates core.BlockstorageClient.ListVolumes, filtering on
+// compartment and availability domain
+func (b *Backend) ListVolumes(ctx context.Context, request core.ListVolumesRequest) (core.ListVolumesResponse, error) {
+	if err := b.begin(ctx, OpListVolumes); err != nil {
+		return core.ListVolumesResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := []core.Volume{} // the SDK cannot unmarshal a null list
+	for _, v := range b.volumes {
+		d := v.details
+		switch {
+		case derefString(request.CompartmentId) != derefString(d.CompartmentId):
+		case request.AvailabilityDomain != nil && *request.AvailabilityDomain != derefString(d.AvailabilityDomain):
+		default:
+			items = append(items, d)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return *items[i].Id < *items[j].Id })
+	return core.ListVolumesResponse{Items: items}, nil
+}
+
+// ListVolumeAttachments simulates core.ComputeClient.ListVolumeAttachments,
+// including detached attachments as the service does for a while after an
+// instance terminates
+func (b *Backend) ListVolumeAttachments(ctx context.Context, request core.ListVolumeAttachmentsRequest) (core.ListVolumeAttachmentsResponse, error) {
+	if err := b.begin(ctx, OpListVolumeAttachments); err != nil {
+		return core.ListVolumeAttachmentsResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var attachments []core.ParavirtualizedVolumeAttachment
+	for _, v := range b.volumes {
+		a := v.attachment
+		switch {
+		case a == nil:
+		case derefString(request.CompartmentId) != derefString(a.CompartmentId):
+		case request.AvailabilityDomain != nil && *request.AvailabilityDomain != derefString(a.AvailabilityDomain):
+		case request.InstanceId != nil && *request.InstanceId != derefString(a.InstanceId):
+		case request.VolumeId != nil && *request.VolumeId != derefString(a.VolumeId):
+		default:
+			attachments = append(attachments, *a)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool { return *attachments[i].Id < *attachments[j].Id })
+	items := []core.VolumeAttachment{} // the SDK cannot unmarshal a null list
+	for _, a := range attachments {
+		items = append(items, a)
+	}
+	return core.ListVolumeAttachmentsResponse{Items: items}, nil
+}
+
+// DeleteVolume simulates core.BlockstorageClient.DeleteVolume. A block volume
+// still attached to an instance cannot be deleted.
+func (b *Backend) DeleteVolume(ctx context.Context, request core.DeleteVolumeRequest) (core.DeleteVolumeResponse, error) {
+	if err := b.begin(ctx, OpDeleteVolume); err != nil {
+		return core.DeleteVolumeResponse{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	v, ok := b.volumes[derefString(request.VolumeId)]
+	if !ok {
+		return core.DeleteVolumeResponse{}, NotFound(derefString(request.VolumeId))
+	}
+	if v.attachment != nil && v.attachment.LifecycleState != core.VolumeAttachmentLifecycleStateDetached {
+		return core.DeleteVolumeResponse{}, Conflict("Volume " + *v.details.Id + " is attached to instance " + derefString(v.attachment.InstanceId))
+	}
+	v.details.LifecycleState = core.VolumeLifecycleStateTerminated
+	return core.DeleteVolumeResponse{}, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// printCounts prints a titled count per key, sorted by key, or nothing when
+// counts is empty
+func printCounts(title string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+	fmt.Println(title)
+	for _, key := range sortedKeys(counts) {
+		fmt.Printf("  %s: %d\n", key, counts[key])
+	}
+}
+
+// confirm asks question on the terminal and reports whether the answer was
+// yes. Anything else, including end of input, is no.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	if answer == "" {
+		fmt.Println()
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Placement is an availability domain and optional fault domain to launch into
+type Placement struct {
+	AvailabilityDomain string `json:"availability_domain"`
+	FaultDomain        string `json:"fault_domain,omitempty"` // empty lets OCI choose
+}
+
+func (p Placement) String() string {
+	if p.FaultDomain == "" {
+		return p.AvailabilityDomain
+	}
+	return p.AvailabilityDomain + "/" + p.FaultDomain
+}
+
+// weightedAD is an availability domain to launch into, with its share of
+// instances under the spread strategy and the fault domains to use within it
+type weightedAD struct {
+	Name         string
+	Weight       int
+	FaultDomains []string // empty lets OCI choose, or uses all three when spreading
+}
+
+// parseAvailabilityDomains parses a comma-separated list of availability
+// domains, each optionally followed by =weight (default 1), e.g. "AD-1=2,AD-2"
+func parseAvailabilityDomains(s string) ([]weightedAD, error) {
+	var ads []weightedAD
+	for _, entry := range splitList(s) {
+		ad := weightedAD{Name: entry, Weight: 1}
+		if name, weight, ok := strings.Cut(entry, "="); ok {
+			w, err := strconv.Atoi(weight)
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid weight %q for availability domain %s", weight, name)
+			}
+			ad = weightedAD{Name: name, Weight: w}
+		}
+		ads = append(ads, ad)
+	}
+	if len(ads) == 0 {
+		return nil, fmt.Errorf("ad flag must name at least one availability domain")
+	}
+	return ads, nil
+}
+
+// orderedPlacements builds the fallback list for the ordered strategy: every
+// fault domain of the first AD, then every fault domain of the next, and so
+// on. ADs without fault domains get defaultFDs.
+func orderedPlacements(ads []weightedAD, defaultFDs []string) []Placement {
+	var placements []Placement
+	for _, ad := range ads {
+		fds := ad.FaultDomains
+		if len(fds) == 0 {
+			fds = defaultFDs
+		}
+		if len(fds) == 0 {
+			fds = []string{""}
+		}
+		for _, fd := range fds {
+			placements = append(placements, Placement{AvailabilityDomain: ad.Name, FaultDomain: fd})
+		}
+	}
+	return placements
+}
+
+// defaultFaultDomains are the fault domains every AD has, used to balance
+// spread placements when -fd is not given
+var defaultFaultDomains = []string{"FAULT-DOMAIN-1", "FAULT-DOMAIN-2", "FAULT-DOMAIN-3"}
+
+// planSpread assigns n instances to placements before launch. ADs are picked
+// by smooth weighted round robin, so equal weights give 10/10/10 for 30
+// instances across three ADs and the picks stay interleaved rather than
+// filling one AD first. Within each AD the fault domains take turns.
+func planSpread(ads []weightedAD, n int) []Placement {
+	total := 0
+	for _, ad := range ads {
+		total += ad.Weight
+	}
+	current := make([]int, len(ads))
+	nextFD := make([]int, len(ads))
+
+	plan := make([]Placement, n)
+	for i := range plan {
+		best := 0
+		for j, ad := range ads {
+			current[j] += ad.Weight
+			if current[j] > current[best] {
+				best = j
+			}
+		}
+		current[best] -= total
+
+		fds := ads[best].FaultDomains
+		if len(fds) == 0 {
+			fds = defaultFaultDomains
+		}
+		plan[i] = Placement{AvailabilityDomain: ads[best].Name, FaultDomain: fds[nextFD[best]%len(fds)]}
+		nextFD[best]++
+	}
+	return plan
+}
+
+// withFallbacks returns all with primary moved to the front and the rest
+// following in their usual order from there, so an instance planned for one
+// placement still falls back to the others on capacity errors
+func withFallbacks(primary Placement, all []Placement) []Placement {
+	start := 0
+	for i, p := range all {
+		if p == primary {
+			start = i
+			break
+		}
+	}
+	out := []Placement{primary}
+	for i := 1; i < len(all); i++ {
+		if p := all[(start+i)%len(all)]; p != primary {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// formatPlan summarises how many instances a plan puts in each AD and fault domain
+func formatPlan(plan []Placement) string {
+	perAD := make(map[string]int)
+	perFD := make(map[string]map[string]int)
+	for _, p := range plan {
+		perAD[p.AvailabilityDomain]++
+		if perFD[p.AvailabilityDomain] == nil {
+			perFD[p.AvailabilityDomain] = make(map[string]int)
+		}
+		perFD[p.AvailabilityDomain][p.FaultDomain]++
+	}
+
+	var parts []string
+	for _, ad := range sortedKeys(perAD) {
+		var fds []string
+		for _, fd := range sortedKeys(perFD[ad]) {
+			fds = append(fds, fmt.Sprintf("%s: %d", fd, perFD[ad][fd]))
+		}
+		parts = append(parts, fmt.Sprintf("%s: %d (%s)", ad, perAD[ad], strings.Join(fds, ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatPlacements joins placements for display
+func formatPlacements(placements []Placement) string {
+	names := make([]string, len(placements))
+	for i, p := range placements {
+		names[i] = p.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAvailabilityDomains(t *testing.T) {
+	got, err := parseAvailabilityDomains("AD-1=2, AD-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []weightedAD{{Name: "AD-1", Weight: 2}, {Name: "AD-2", Weight: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	for _, s := range []string{"", "AD-1=0", "AD-1=x"} {
+		if _, err := parseAvailabilityDomains(s); err == nil {
+			t.Errorf("parseAvailabilityDomains(%q): want an error", s)
+		}
+	}
+}
+
+func TestPlanSpreadEqualWeights(t *testing.T) {
+	ads := []weightedAD{{Name: "AD-1", Weight: 1}, {Name: "AD-2", Weight: 1}, {Name: "AD-3", Weight: 1}}
+	plan := planSpread(ads, 30)
+	if len(plan) != 30 {
+		t.Fatalf("planned %d placements, want 30", len(plan))
+	}
+	perAD := make(map[string]int)
+	perFD := make(map[Placement]int)
+	for i, p := range plan {
+		perAD[p.AvailabilityDomain]++
+		perFD[p]++
+		if i > 0 && p.AvailabilityDomain == plan[i-1].AvailabilityDomain {
+			t.Errorf("placements %d and %d are both in %s, want the ADs interleaved", i-1, i, p.AvailabilityDomain)
+		}
+	}
+	for _, ad := range ads {
+		if perAD[ad.Name] != 10 {
+			t.Errorf("%s got %d instances, want 10", ad.Name, perAD[ad.Name])
+		}
+	}
+	if len(perFD) != 9 {
+		t.Errorf("used %d fault domains, want all 3 in each of the 3 ADs", len(perFD))
+	}
+	for p, n := range perFD {
+		if n < 3 || n > 4 {
+			t.Errorf("%s got %d instances, want 3 or 4", p, n)
+		}
+	}
+}
+
+func TestPlanSpreadWeightsAndFaultDomains(t *testing.T) {
+	ads := []weightedAD{
+		{Name: "AD-1", Weight: 2, FaultDomains: []string{"FAULT-DOMAIN-2"}},
+		{Name: "AD-2", Weight: 1},
+	}
+	want := []Placement{
+		{AvailabilityDomain: "AD-1", FaultDomain: "FAULT-DOMAIN-2"},
+		{AvailabilityDomain: "AD-2", FaultDomain: "FAULT-DOMAIN-1"},
+		{AvailabilityDomain: "AD-1", FaultDomain: "FAULT-DOMAIN-2"},
+		{AvailabilityDomain: "AD-1", FaultDomain: "FAULT-DOMAIN-2"},
+		{AvailabilityDomain: "AD-2", FaultDomain: "FAULT-DOMAIN-2"},
+		{AvailabilityDomain: "AD-1", FaultDomain: "FAULT-DOMAIN-2"},
+	}
+	if got := planSpread(ads, 6); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := planSpread(ads, 0); len(got) != 0 {
+		t.Errorf("planSpread of 0 = %v, want none", got)
+	}
+}
+
+func TestWithFallbacks(t *testing.T) {
+	all := orderedPlacements([]weightedAD{{Name: "AD-1"}, {Name: "AD-2"}, {Name: "AD-3"}}, nil)
+	got := withFallbacks(Placement{AvailabilityDomain: "AD-2"}, all)
+	want := []Placement{{AvailabilityDomain: "AD-2"}, {AvailabilityDomain: "AD-3"}, {AvailabilityDomain: "AD-1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
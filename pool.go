@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// poolAction is a pool subcommand
+type poolAction struct {
+	Name    string
+	Summary string
+}
+
+var poolActions = []poolAction{
+	{"create", "Create an instance pool from the config, falling back across its shapes"},
+	{"scale", "Set the size of an instance pool, or change it by a number or percentage"},
+	{"list", "List the instances in an instance pool"},
+	{"terminate", "Terminate an instance pool and all its instances"},
+	{"detach", "Detach instances from a pool, by ID, age or fault domain, and terminate them"},
+}
+
+func printPoolUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: oci-insta-scale pool <action> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Actions:")
+	for _, a := range poolActions {
+		fmt.Fprintf(w, "  %-10s %s\n", a.Name, a.Summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'oci-insta-scale pool <action> -h' for the flags an action takes.")
+}
+
+// runPool implements the pool command
+func runPool(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		printPoolUsage(os.Stderr)
+		return errUsage
+	}
+	var action *poolAction
+	for i := range poolActions {
+		if poolActions[i].Name == args[0] {
+			action = &poolActions[i]
+		}
+	}
+	if action == nil {
+		switch args[0] {
+		case "-h", "-help", "--help":
+			printPoolUsage(os.Stdout)
+			return flag.ErrHelp
+		}
+		fmt.Fprintf(os.Stderr, "Unknown pool action: %s\n\n", args[0])
+		printPoolUsage(os.Stderr)
+		return errUsage
+	}
+
+	// Command-line flags. Every action takes the config and compartment; the
+	// rest only where they apply.
+	usageArgs := ""
+	if action.Name == "scale" {
+		usageArgs = " [pool-id] [N | +N | -N | N%]"
+	}
+	fs := newFlagSet("pool "+action.Name, usageArgs, action.Summary+".")
+	compartmentID := fs.String("compartment", "", "Compartment OCID (overrides config)")
+	var (
+		instanceCount  = new(int)
+		displayName    = new(string)
+		instancePoolID = new(string)
+		instanceID     = new(string)
+		runID          = new(string)
+		ttl            = new(time.Duration)
+		oldest         = new(int)
+		faultDomains   = new(string)
+		decrement      = new(bool)
+		terminate      = new(bool)
+		waitTimeout    = new(time.Duration)
+		assumeYes      = new(bool)
+		dryRun         = new(bool)
+		wait           = new(bool)
+		newSize        = new(string)
+		maxStep        = new(int)
+		scaleInOrder   = new(string)
+		protectTags    = new(string)
+	)
+	switch action.Name {
+	case "create":
+		fs.IntVar(instanceCount, "count", 0, "Number of instances in the pool (overrides config)")
+		fs.StringVar(displayName, "name", "", "Instance pool display name (overrides config)")
+		fs.StringVar(runID, "run-id", "", "Run ID to create as (default: a new one); repeating a create with the same run ID returns the pool it made")
+		fs.DurationVar(ttl, "ttl", 0, "Tag the pool and its instances to expire this long after creation, e.g. 4h (overrides config; 0 for never)")
+		fs.BoolVar(wait, "wait", false, "Wait for the pool and every instance to be RUNNING, showing each instance's progress")
+		fs.DurationVar(waitTimeout, "wait-timeout", 20*time.Minute, "How long -wait waits for the pool")
+	case "scale":
+		fs.StringVar(newSize, "count", "", "New size: N, or +N, -N or N% of the current size; may also be given after the flags (default: size from config)")
+		fs.StringVar(instancePoolID, "pool-id", "", "Instance pool ID (required unless given before the new size after the flags)")
+		fs.StringVar(scaleInOrder, "scale-in", "", "When shrinking, remove instances in this order instead of letting OCI choose: "+strings.Join(scaleInOrders, ", ")+" (overrides config)")
+		fs.StringVar(protectTags, "protect-tag", "", "When shrinking, never remove instances with these comma-separated freeform tags, as key or key=value (overrides config)")
+		fs.IntVar(maxStep, "max-step", 0, "Change the size by at most this many instances at a time, waiting for RUNNING in between (overrides config; 0 for no limit)")
+		fs.BoolVar(wait, "wait", false, "Wait for the pool and every new instance to be RUNNING, showing each instance's progress")
+		fs.DurationVar(waitTimeout, "wait-timeout", 20*time.Minute, "How long -wait waits for the pool")
+	case "detach":
+		fs.StringVar(instancePoolID, "pool-id", "", "Instance pool ID (required)")
+		fs.StringVar(instanceID, "instance-id", "", "Comma-separated instance IDs to detach")
+		fs.IntVar(oldest, "oldest", 0, "Detach the N oldest instances (within -fd, if given)")
+		fs.StringVar(faultDomains, "fd", "", "Detach the instances in these comma-separated fault domains (all of them unless -oldest is given)")
+		fs.BoolVar(decrement, "decrement", true, "Shrink the pool by each detached instance instead of letting it launch a replacement")
+		fs.BoolVar(terminate, "terminate", true, "Terminate each detached instance instead of leaving it running outside the pool")
+		fs.DurationVar(waitTimeout, "wait-timeout", 20*time.Minute, "How long to wait for the pool to return to RUNNING before and after each detach")
+		fs.BoolVar(assumeYes, "yes", false, "Detach the instances -oldest or -fd select without asking for confirmation")
+		fs.BoolVar(dryRun, "dry-run", false, "Show the instances that would be detached without detaching them")
+	default:
+		fs.StringVar(instancePoolID, "pool-id", "", "Instance pool ID (required)")
+	}
+	shared := registerCommonFlags(fs, "config.yaml")
+	flagArgs := args[1:]
+	if action.Name == "scale" {
+		flagArgs = sizeAfterFlags(fs, flagArgs)
+	}
+	if err := parseFlags(fs, flagArgs); err != nil {
+		return err
+	}
+
+	// Load configuration
+	config, err := shared.load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Override config with command-line flags if provided
+	set := flagsSet(fs)
+	if *instanceCount > 0 {
+		config.InstancePool.Size = *instanceCount
+	}
+	if *compartmentID != "" {
+		config.CompartmentID = *compartmentID
+	}
+	if *displayName != "" {
+		config.InstancePool.DisplayName = *displayName
+	}
+	if set["ttl"] {
+		config.TTL = *ttl
+	}
+	if set["max-step"] {
+		config.InstancePool.MaxStep = *maxStep
+	}
+
+	// The new size for scale comes from -count, the argument after the flags
+	// or the config, in that order. Two arguments are the pool ID and the size.
+	var change sizeChange
+	if action.Name == "scale" {
+		sizeArgs := fs.Args()
+		if len(sizeArgs) == 2 && *instancePoolID == "" {
+			*instancePoolID, sizeArgs = sizeArgs[0], sizeArgs[1:]
+		}
+		switch {
+		case *newSize != "" && len(sizeArgs) > 0:
+			return usageErrorf(fs, "give the new size with -count or as an argument, not both")
+		case len(sizeArgs) > 1:
+			return usageErrorf(fs, "unexpected arguments after the new size: %s", strings.Join(sizeArgs[1:], " "))
+		case len(sizeArgs) == 1:
+			*newSize = sizeArgs[0]
+		case *newSize == "" && config.InstancePool.Size > 0:
+			*newSize = strconv.Itoa(config.InstancePool.Size)
+		}
+		if *newSize == "" {
+			return usageErrorf(fs, "instance pool size must be greater than 0. Use -count to specify the number of instances.")
+		}
+		if change, err = parseSizeChange(*newSize); err != nil {
+			return usageErrorf(fs, "%v", err)
+		}
+		if err := config.InstancePool.validateBounds(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		if *scaleInOrder != "" {
+			config.InstancePool.ScaleIn.Order = *scaleInOrder
+		}
+		if *protectTags != "" {
+			config.InstancePool.ScaleIn.ProtectedTags = splitList(*protectTags)
+		}
+		if err := config.InstancePool.ScaleIn.validate(); err != nil {
+			return usageErrorf(fs, "%v", err)
+		}
+	}
+
+	switch {
+	case action.Name == "create":
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+		if config.InstancePool.Size <= 0 {
+			return usageErrorf(fs, "instance pool size must be greater than 0. Use -count to specify the number of instances.")
+		}
+		if err := config.InstancePool.checkSize(config.InstancePool.Size); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	case *instancePoolID == "":
+		return usageErrorf(fs, "-pool-id is required for %s", action.Name)
+	case action.Name == "detach" && *instanceID == "" && *oldest <= 0 && *faultDomains == "":
+		return usageErrorf(fs, "-instance-id, -oldest or -fd is required for detach")
+	case action.Name == "detach" && *instanceID != "" && (*oldest > 0 || *faultDomains != ""):
+		return usageErrorf(fs, "-instance-id cannot be combined with -oldest or -fd")
+	case action.Name == "detach" && *oldest < 0:
+		return usageErrorf(fs, "-oldest must not be negative")
+	}
+
+	// Initialize OCI client
+	client, err := NewOCIClient(config)
+	if err != nil {
+		return fmt.Errorf("failed to initialize OCI client: %w", err)
+	}
+
+	// Perform action
+	switch action.Name {
+	case "create":
+		if *runID == "" {
+			*runID = newRunID(time.Now())
+		}
+		fmt.Printf("Creating instance pool with %d instances (run ID: %s)...\n", config.InstancePool.Size, *runID)
+		requestedAt := time.Now().UTC()
+		pool, shape, err := client.CreateInstancePool(ctx, config, *runID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Successfully created instance pool: %s (ID: %s) with shape %s\n", *pool.DisplayName, *pool.Id, shape)
+		if !*wait {
+			fmt.Printf("Instance pool is now provisioning. Check OCI console for status.\n")
+			break
+		}
+		fmt.Println("Waiting for the instance pool to be RUNNING...")
+		watch := newPoolWatch(client, *pool.Id, config.CompartmentID, requestedAt, nil)
+		if err := watch.wait(ctx, poolPollInterval, *waitTimeout); err != nil {
+			return err
+		}
+
+	case "scale":
+		if err := scalePool(ctx, client, config, *instancePoolID, change, config.InstancePool.MaxStep, *wait, *waitTimeout); err != nil {
+			return err
+		}
+
+	case "terminate":
+		fmt.Printf("Terminating instance pool %s...\n", *instancePoolID)
+		err := client.TerminateInstancePool(ctx, *instancePoolID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Successfully terminated instance pool\n")
+
+	case "detach":
+		members, err := client.ListInstancePoolInstances(ctx, config.CompartmentID, *instancePoolID)
+		if err != nil {
+			return err
+		}
+		ids := splitList(*instanceID)
+		selected, err := selectPoolMembers(members, ids, splitList(*faultDomains), *oldest)
+		if err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			fmt.Println("No instances in the pool match the selectors")
+			return nil
+		}
+		if len(ids) == 0 {
+			fmt.Printf("%d of the pool's %d instance(s) match the selectors:\n", len(selected), len(members))
+			printPoolMemberPreview(selected)
+			if *dryRun {
+				fmt.Println("\nDry run: nothing was detached")
+				return nil
+			}
+			if !*assumeYes && !confirm(fmt.Sprintf("\nDetach these %d instance(s)?", len(selected))) {
+				fmt.Println("Nothing was detached")
+				return nil
+			}
+		} else if *dryRun {
+			printPoolMemberPreview(selected)
+			fmt.Println("\nDry run: nothing was detached")
+			return nil
+		}
+
+		resize := "shrinking the pool"
+		if !*decrement {
+			resize = "letting the pool replace them"
+		}
+		outcome := "terminating them"
+		if !*terminate {
+			outcome = "leaving them running"
+		}
+		fmt.Printf("Detaching %d instance(s) from pool %s, %s and %s...\n", len(selected), *instancePoolID, resize, outcome)
+		failures := detachInstances(ctx, client, *instancePoolID, selected, *decrement, *terminate, *waitTimeout)
+		fmt.Printf("\nSummary: %d/%d instances detached\n", len(selected)-failures, len(selected))
+		if failures > 0 {
+			return fmt.Errorf("%d of %d instances could not be detached", failures, len(selected))
+		}
+
+	case "list":
+		fmt.Printf("Listing instances in pool %s...\n", *instancePoolID)
+		instances, err := client.ListInstancePoolInstances(ctx, config.CompartmentID, *instancePoolID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nFound %d instances:\n", len(instances))
+		byShape := make(map[string]int)
+		for i, inst := range instances {
+			fmt.Printf("%d. ID: %s\n", i+1, *inst.Id)
+			fmt.Printf("   Display Name: %s\n", *inst.DisplayName)
+			fmt.Printf("   State: %s\n", *inst.State)
+			fmt.Printf("   AD: %s\n", *inst.AvailabilityDomain)
+			if inst.FaultDomain != nil {
+				fmt.Printf("   Fault Domain: %s\n", *inst.FaultDomain)
+			}
+			if inst.Shape != nil {
+				fmt.Printf("   Shape: %s\n", *inst.Shape)
+				byShape[*inst.Shape]++
+			}
+			fmt.Println()
+		}
+		printCounts("Instances per shape:", byShape)
+	}
+	return nil
+}
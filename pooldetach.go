@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// poolPollInterval is how often a pool's state is checked while waiting for it
+const poolPollInterval = 5 * time.Second
+
+// selectPoolMembers picks the pool members to act on: those in ids, or
+// otherwise those in one of faultDomains (any if empty), limited to the
+// oldest N when oldest is positive. An ID that is not a member is an error.
+func selectPoolMembers(members []core.InstanceSummary, ids, faultDomains []string, oldest int) ([]core.InstanceSummary, error) {
+	if len(ids) > 0 {
+		byID := make(map[string]core.InstanceSummary, len(members))
+		for _, m := range members {
+			byID[derefString(m.Id)] = m
+		}
+		var selected []core.InstanceSummary
+		for _, id := range ids {
+			m, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("instance %s is not in the pool", id)
+			}
+			selected = append(selected, m)
+		}
+		return selected, nil
+	}
+
+	inFD := make(map[string]bool)
+	for _, fd := range faultDomains {
+		inFD[fd] = true
+	}
+	var selected []core.InstanceSummary
+	for _, m := range members {
+		if len(inFD) == 0 || inFD[derefString(m.FaultDomain)] {
+			selected = append(selected, m)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return summaryCreated(selected[i]).Before(summaryCreated(selected[j]))
+	})
+	if oldest > 0 && len(selected) > oldest {
+		selected = selected[:oldest]
+	}
+	return selected, nil
+}
+
+// summaryCreated returns when a pool member was created, or the zero time if not known
+func summaryCreated(inst core.InstanceSummary) time.Time {
+	if inst.TimeCreated == nil {
+		return time.Time{}
+	}
+	return inst.TimeCreated.Time
+}
+
+// printPoolMemberPreview lists pool members one per line with the details
+// needed to check a selection before acting on it
+func printPoolMemberPreview(members []core.InstanceSummary) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tSTATE\tAVAILABILITY DOMAIN\tFAULT DOMAIN\tCREATED\tID")
+	for _, m := range members {
+		created := ""
+		if t := summaryCreated(m); !t.IsZero() {
+			created = t.UTC().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", derefString(m.DisplayName), derefString(m.State),
+			derefString(m.AvailabilityDomain), derefString(m.FaultDomain), created, derefString(m.Id))
+	}
+	w.Flush()
+}
+
+// waitForPoolRunning polls until the pool is RUNNING and returns it. The
+// service rejects changes to a pool that is still provisioning or scaling, so
+// each detach waits for the previous one to settle.
+func waitForPoolRunning(ctx context.Context, client *OCIClient, instancePoolID string, interval, maxWait time.Duration) (*core.InstancePool, error) {
+	ctxWait, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Immediate check before waiting
+	pool, err := client.GetInstancePool(ctxWait, instancePoolID)
+	for err == nil && pool.LifecycleState != core.InstancePoolLifecycleStateRunning {
+		switch pool.LifecycleState {
+		case core.InstancePoolLifecycleStateTerminating, core.InstancePoolLifecycleStateTerminated:
+			return nil, fmt.Errorf("instance pool %s is %s", instancePoolID, pool.LifecycleState)
+		}
+		select {
+		case <-ctxWait.Done():
+			return nil, fmt.Errorf("timeout waiting for instance pool to be RUNNING, last seen %s: %w", pool.LifecycleState, ctxWait.Err())
+		case <-ticker.C:
+			pool, err = client.GetInstancePool(ctxWait, instancePoolID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// detachInstances detaches members from the pool one at a time, waiting for
+// the pool to be RUNNING before each detach and after the last, and returns
+// how many could not be detached. A detach is not attempted once the pool
+// fails to settle, since every later one would be rejected too.
+func detachInstances(ctx context.Context, client *OCIClient, instancePoolID string, members []core.InstanceSummary, decrement, terminate bool, maxWait time.Duration) int {
+	detached := 0
+	for i, m := range members {
+		id := derefString(m.Id)
+		if _, err := waitForPoolRunning(ctx, client, instancePoolID, poolPollInterval, maxWait); err != nil {
+			fmt.Printf("❌ Stopped before detaching %s: %v\n", id, err)
+			return len(members) - detached
+		}
+		if err := client.DetachInstance(ctx, instancePoolID, id, decrement, terminate); err != nil {
+			fmt.Printf("❌ Failed to detach %s: %v\n", id, err)
+			continue
+		}
+		detached++
+		fmt.Printf("✓ Detached %s (%s, %s) [%d/%d]\n", id, derefString(m.DisplayName), derefString(m.FaultDomain), i+1, len(members))
+	}
+
+	if detached > 0 {
+		pool, err := waitForPoolRunning(ctx, client, instancePoolID, poolPollInterval, maxWait)
+		if err != nil {
+			fmt.Printf("❌ Instance pool did not return to RUNNING: %v\n", err)
+		} else {
+			fmt.Printf("Instance pool is RUNNING with %d instances\n", *pool.Size)
+		}
+	}
+	return len(members) - detached
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+// member returns a pool member launched the given number of hours after a
+// fixed start, in fault domain fd of AD-1
+func member(id, fd string, hour int) core.InstanceSummary {
+	created := time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)
+	return core.InstanceSummary{
+		Id:                 common.String(id),
+		AvailabilityDomain: common.String("AD-1"),
+		FaultDomain:        common.String(fd),
+		State:              common.String("Running"),
+		TimeCreated:        &common.SDKTime{Time: created},
+	}
+}
+
+func ids(members []core.InstanceSummary) []string {
+	var out []string
+	for _, m := range members {
+		out = append(out, derefString(m.Id))
+	}
+	return out
+}
+
+func TestSelectPoolMembers(t *testing.T) {
+	members := []core.InstanceSummary{
+		member("c", "FAULT-DOMAIN-2", 3),
+		member("a", "FAULT-DOMAIN-1", 1),
+		member("d", "FAULT-DOMAIN-3", 4),
+		member("b", "FAULT-DOMAIN-2", 2),
+	}
+
+	tests := []struct {
+		name         string
+		ids          []string
+		faultDomains []string
+		oldest       int
+		want         []string
+	}{
+		{"all, oldest first", nil, nil, 0, []string{"a", "b", "c", "d"}},
+		{"oldest 2", nil, nil, 2, []string{"a", "b"}},
+		{"oldest more than there are", nil, nil, 9, []string{"a", "b", "c", "d"}},
+		{"by fault domain", nil, []string{"FAULT-DOMAIN-2", "FAULT-DOMAIN-3"}, 0, []string{"b", "c", "d"}},
+		{"oldest in a fault domain", nil, []string{"FAULT-DOMAIN-2"}, 1, []string{"b"}},
+		{"by ID in the order given", []string{"d", "a"}, []string{"FAULT-DOMAIN-2"}, 1, []string{"d", "a"}},
+	}
+	for _, tt := range tests {
+		got, err := selectPoolMembers(members, tt.ids, tt.faultDomains, tt.oldest)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(ids(got), tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, ids(got), tt.want)
+		}
+	}
+
+	if _, err := selectPoolMembers(members, []string{"a", "z"}, nil, 0); err == nil {
+		t.Error("selecting an ID that is not a member: want an error")
+	}
+}
+
+func TestListInstancePoolInstancesFollowsPages(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 7)
+	b.InjectError(ocifake.OpListInstancePoolInstances, ocifake.TooManyRequests(), 1)
+	before := b.Calls(ocifake.OpListInstancePoolInstances)
+
+	members, err := fakeClient(b).ListInstancePoolInstances(context.Background(), "ocid1.compartment.test", poolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 7 {
+		t.Errorf("listed %d members, want all 7", len(members))
+	}
+	// The fake returns 3 members a page, and the first call is throttled
+	if calls := b.Calls(ocifake.OpListInstancePoolInstances) - before; calls != 4 {
+		t.Errorf("made %d ListInstancePoolInstances calls, want 3 pages and a retry", calls)
+	}
+}
+
+func TestDetachInstances(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 4)
+	client := fakeClient(b)
+	members, err := client.ListInstancePoolInstances(context.Background(), "ocid1.compartment.test", poolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missing := member("ocid1.instance.missing", "FAULT-DOMAIN-1", 0)
+	batch := []core.InstanceSummary{members[0], missing, members[1]}
+	if failures := detachInstances(context.Background(), client, poolID, batch, true, true, time.Minute); failures != 1 {
+		t.Errorf("%d failures, want 1 for the instance not in the pool", failures)
+	}
+	pool, err := client.GetInstancePool(context.Background(), poolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pool.Size != 2 {
+		t.Errorf("pool size %d after detaching 2 with decrement, want 2", *pool.Size)
+	}
+	for _, m := range members[:2] {
+		if state := instanceState(t, b, *m.Id); state != core.InstanceLifecycleStateTerminated {
+			t.Errorf("detached %s is %s, want TERMINATED", *m.Id, state)
+		}
+	}
+}
+
+func TestDetachInstancesStopsWhenThePoolDoesNotSettle(t *testing.T) {
+	b := ocifake.New(ocifake.WithTimeline(ocifake.Timeline{Provisioning: time.Hour}))
+	poolID := createFakePool(t, b, 2)
+	client := fakeClient(b)
+	members, err := client.ListInstancePoolInstances(context.Background(), "ocid1.compartment.test", poolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failures := detachInstances(context.Background(), client, poolID, members, true, true, 20*time.Millisecond); failures != 2 {
+		t.Errorf("%d failures, want both left attached", failures)
+	}
+	if n := b.Calls(ocifake.OpDetachInstancePoolInstance); n != 0 {
+		t.Errorf("made %d detach calls to a provisioning pool, want none", n)
+	}
+}
+
+// instanceState returns the lifecycle state the fake reports for id
+func instanceState(t *testing.T, b *ocifake.Backend, id string) core.InstanceLifecycleStateEnum {
+	t.Helper()
+	resp, err := b.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: common.String(id)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.LifecycleState
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeChange is a requested pool size: an absolute size, a change relative to
+// the current size, or a percentage of it
+type sizeChange struct {
+	Value    int
+	Relative bool // Value instances are added to, or if negative removed from, the current size
+	Percent  bool // the new size is Value percent of the current size
+}
+
+// parseSizeChange parses N, +N, -N or N%
+func parseSizeChange(s string) (sizeChange, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid size %q: use N, +N, -N or N%%", s)
+	switch {
+	case strings.HasSuffix(s, "%"):
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		if err != nil || n < 0 {
+			return sizeChange{}, invalid
+		}
+		return sizeChange{Value: n, Percent: true}, nil
+	case strings.HasPrefix(s, "+"), strings.HasPrefix(s, "-"):
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return sizeChange{}, invalid
+		}
+		return sizeChange{Value: n, Relative: true}, nil
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return sizeChange{}, invalid
+		}
+		return sizeChange{Value: n}, nil
+	}
+}
+
+// sizeAfterFlags moves a negative size given after the flags, such as the -3
+// in "pool scale -pool-id X -3", behind a "--" so the flag package reads it as
+// the new size rather than as an unknown flag. A value following a flag that
+// takes one, as in "-count -3", is left where it is, as is everything from the
+// first argument that is not a flag, such as a pool ID, or an explicit "--".
+func sizeAfterFlags(fs *flag.FlagSet, args []string) []string {
+	takesValue := func(arg string) bool {
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			return false
+		}
+		f := fs.Lookup(name)
+		if f == nil {
+			return false
+		}
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		return !ok || !b.IsBoolFlag()
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") || arg == "-" {
+			return args
+		}
+		if _, err := strconv.Atoi(arg); err == nil {
+			moved := append(append([]string{}, args[:i]...), args[i+1:]...)
+			return append(moved, "--", arg)
+		}
+		if takesValue(arg) {
+			i++
+		}
+	}
+	return args
+}
+
+// resolve returns the size the change asks for of a pool with current instances
+func (c sizeChange) resolve(current int) int {
+	switch {
+	case c.Percent:
+		return int(math.Round(float64(current) * float64(c.Value) / 100))
+	case c.Relative:
+		return current + c.Value
+	default:
+		return c.Value
+	}
+}
+
+// String returns the change as it was written
+func (c sizeChange) String() string {
+	switch {
+	case c.Percent:
+		return fmt.Sprintf("%d%%", c.Value)
+	case c.Relative:
+		return fmt.Sprintf("%+d", c.Value)
+	default:
+		return strconv.Itoa(c.Value)
+	}
+}
+
+// scaleSteps returns the sizes to set in turn to get from current to target,
+// changing the size by at most maxStep each time (any amount if maxStep is 0)
+func scaleSteps(current, target, maxStep int) []int {
+	if maxStep <= 0 {
+		return []int{target}
+	}
+	var steps []int
+	for size := current; size != target; {
+		switch {
+		case target > size:
+			size = min(size+maxStep, target)
+		default:
+			size = max(size-maxStep, target)
+		}
+		steps = append(steps, size)
+	}
+	return steps
+}
+
+// scalePool resolves change against the pool's current size and the config's
+// min_size and max_size, then sets the new size in steps of at most maxStep,
+// waiting for the pool to be RUNNING between steps. With wait it then follows
+// the new instances until the pool is RUNNING, as pool create -wait does.
+// A shrink under a scale-in policy removes the instances the policy picks
+// instead, in the same steps.
+func scalePool(ctx context.Context, client *OCIClient, config *Config, instancePoolID string, change sizeChange, maxStep int, wait bool, waitTimeout time.Duration) error {
+	pool, err := client.GetInstancePool(ctx, instancePoolID)
+	if err != nil {
+		return err
+	}
+	current := 0
+	if pool.Size != nil {
+		current = *pool.Size
+	}
+
+	// An absolute size outside the bounds is a mistake; a relative change
+	// that would cross one stops at it
+	bounds := config.InstancePool
+	target := change.resolve(current)
+	if change.Relative || change.Percent {
+		if bounded := bounds.clampSize(target); bounded != target {
+			fmt.Printf("Size %s of %d is %d, held to %d by the pool's min_size/max_size\n", change, current, target, bounded)
+			target = bounded
+		}
+	} else if err := bounds.checkSize(target); err != nil {
+		return err
+	}
+	if target <= 0 {
+		return fmt.Errorf("instance pool size must be greater than 0 (%s of %d is %d); use pool terminate to remove the pool", change, current, target)
+	}
+	if target == current {
+		fmt.Printf("Instance pool %s already has %d instances\n", instancePoolID, current)
+		return nil
+	}
+
+	if target < current && config.InstancePool.ScaleIn.enabled() {
+		return scaleIn(ctx, client, config, instancePoolID, config.InstancePool.ScaleIn, current, target, maxStep, wait, waitTimeout)
+	}
+
+	// Members from before the scale are not timed when waiting
+	existing := make(map[string]bool)
+	if wait {
+		members, err := client.ListInstancePoolInstances(ctx, config.CompartmentID, instancePoolID)
+		if err != nil {
+			return err
+		}
+		for _, m := range members {
+			existing[derefString(m.Id)] = true
+		}
+	}
+
+	steps := scaleSteps(current, target, maxStep)
+	requestedAt := time.Now().UTC()
+	for i, size := range steps {
+		if len(steps) == 1 {
+			fmt.Printf("Scaling instance pool %s from %d to %d instances...\n", instancePoolID, current, size)
+		} else {
+			if i > 0 {
+				if _, err := waitForPoolRunning(ctx, client, instancePoolID, poolPollInterval, waitTimeout); err != nil {
+					return fmt.Errorf("step %d/%d: %w", i+1, len(steps), err)
+				}
+			}
+			fmt.Printf("Scaling instance pool %s to %d instances (step %d/%d)...\n", instancePoolID, size, i+1, len(steps))
+		}
+		if err := client.ScaleInstancePool(ctx, instancePoolID, size); err != nil {
+			return fmt.Errorf("failed to scale instance pool: %w", err)
+		}
+	}
+	fmt.Printf("Successfully scaled instance pool to %d instances\n", target)
+
+	if wait {
+		fmt.Println("Waiting for the instance pool to be RUNNING...")
+		watch := newPoolWatch(client, instancePoolID, config.CompartmentID, requestedAt, existing)
+		return watch.wait(ctx, poolPollInterval, waitTimeout)
+	}
+	return nil
+}
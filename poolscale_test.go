@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+func TestParseSizeChange(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    sizeChange
+		current int
+		size    int
+	}{
+		{"5", sizeChange{Value: 5}, 3, 5},
+		{"0", sizeChange{Value: 0}, 3, 0},
+		{"+2", sizeChange{Value: 2, Relative: true}, 3, 5},
+		{"-3", sizeChange{Value: -3, Relative: true}, 8, 5},
+		{"150%", sizeChange{Value: 150, Percent: true}, 3, 5},
+		{" 50% ", sizeChange{Value: 50, Percent: true}, 5, 3},
+	}
+	for _, tt := range tests {
+		got, err := parseSizeChange(tt.in)
+		if err != nil {
+			t.Errorf("parseSizeChange(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSizeChange(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if size := got.resolve(tt.current); size != tt.size {
+			t.Errorf("%q of %d = %d, want %d", tt.in, tt.current, size, tt.size)
+		}
+	}
+
+	for _, in := range []string{"", "x", "-1%", "+", "2.5", "-5x"} {
+		if got, err := parseSizeChange(in); err == nil {
+			t.Errorf("parseSizeChange(%q) = %+v, want an error", in, got)
+		}
+	}
+}
+
+func TestScaleSteps(t *testing.T) {
+	tests := []struct {
+		current, target, maxStep int
+		want                     []int
+	}{
+		{2, 10, 0, []int{10}},
+		{2, 10, 3, []int{5, 8, 10}},
+		{10, 2, 4, []int{6, 2}},
+		{3, 1, 1, []int{2, 1}},
+		{4, 4, 2, nil},
+	}
+	for _, tt := range tests {
+		if got := scaleSteps(tt.current, tt.target, tt.maxStep); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("scaleSteps(%d, %d, %d) = %v, want %v", tt.current, tt.target, tt.maxStep, got, tt.want)
+		}
+	}
+}
+
+func TestSizeAfterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("scale", flag.ContinueOnError)
+	fs.String("pool-id", "", "")
+	fs.String("count", "", "")
+	fs.Bool("wait", false, "")
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-pool-id", "X", "-3"}, []string{"-pool-id", "X", "--", "-3"}},
+		{[]string{"-wait", "-3", "-pool-id", "X"}, []string{"-wait", "-pool-id", "X", "--", "-3"}},
+		{[]string{"-count", "-3", "-pool-id", "X"}, []string{"-count", "-3", "-pool-id", "X"}},
+		{[]string{"-pool-id", "X", "--", "-3"}, []string{"-pool-id", "X", "--", "-3"}},
+		{[]string{"-pool-id", "X", "+3"}, []string{"-pool-id", "X", "+3"}},
+		{[]string{"-wait", "X", "-3"}, []string{"-wait", "X", "-3"}},
+		{[]string{"--", "X", "-3"}, []string{"--", "X", "-3"}},
+	}
+	for _, tt := range tests {
+		if got := sizeAfterFlags(fs, tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("sizeAfterFlags(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+// scaleFake scales the fake pool by change and returns its new size
+func scaleFake(t *testing.T, b *ocifake.Backend, config *Config, poolID, change string, maxStep int) (int, error) {
+	t.Helper()
+	c, err := parseSizeChange(change)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fakeClient(b)
+	scaleErr := scalePool(context.Background(), client, config, poolID, c, maxStep, true, time.Minute)
+	pool, err := client.GetInstancePool(context.Background(), poolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *pool.Size, scaleErr
+}
+
+func TestScalePoolInSteps(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 2)
+
+	size, err := scaleFake(t, b, testPoolConfig(2), poolID, "+3", 2)
+	if err != nil || size != 5 {
+		t.Fatalf("scaled to %d with %v, want 5", size, err)
+	}
+	if n := b.Calls(ocifake.OpUpdateInstancePool); n != 2 {
+		t.Errorf("made %d UpdateInstancePool calls, want 2 steps", n)
+	}
+
+	size, err = scaleFake(t, b, testPoolConfig(2), poolID, "40%", 0)
+	if err != nil || size != 2 {
+		t.Fatalf("scaled to %d with %v, want 2", size, err)
+	}
+	if n := b.Calls(ocifake.OpUpdateInstancePool); n != 3 {
+		t.Errorf("made %d UpdateInstancePool calls, want one more", n)
+	}
+}
+
+func TestScalePoolBounds(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 2)
+	config := testPoolConfig(2)
+	config.InstancePool.MinSize = 1
+	config.InstancePool.MaxSize = 4
+
+	if size, err := scaleFake(t, b, config, poolID, "+5", 0); err != nil || size != 4 {
+		t.Errorf("+5 scaled to %d with %v, want held to 4", size, err)
+	}
+	if size, err := scaleFake(t, b, config, poolID, "9", 0); err == nil || size != 4 {
+		t.Errorf("9 scaled to %d with %v, want an error and no change", size, err)
+	}
+	if size, err := scaleFake(t, b, testPoolConfig(2), poolID, "-4", 0); err == nil || size != 4 {
+		t.Errorf("-4 scaled to %d with %v, want an error and no change", size, err)
+	}
+}
+
+func TestScalePoolShrinksByScaleInPolicy(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 3)
+	config := testPoolConfig(3)
+	config.InstancePool.ScaleIn = ScaleInPolicy{Order: scaleInNewest}
+
+	if size, err := scaleFake(t, b, config, poolID, "-1", 0); err != nil || size != 2 {
+		t.Fatalf("scaled to %d with %v, want 2", size, err)
+	}
+	if n := b.Calls(ocifake.OpDetachInstancePoolInstance); n != 1 {
+		t.Errorf("made %d detach calls, want 1", n)
+	}
+	if n := b.Calls(ocifake.OpUpdateInstancePool); n != 0 {
+		t.Errorf("made %d UpdateInstancePool calls, want none", n)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// poolWatch follows an instance pool after a create or scale until it is
+// RUNNING with every member running, printing each member's state changes
+type poolWatch struct {
+	client        *OCIClient
+	poolID        string
+	compartmentID string
+	requestedAt   time.Time            // when the create or scale was requested
+	existing      map[string]bool      // members from before the request, not timed
+	states        map[string]string    // last state seen per member
+	runningAt     map[string]time.Time // when each new member was first seen running
+}
+
+// newPoolWatch returns a watch on poolID timing the members that are not in
+// existing from requestedAt
+func newPoolWatch(client *OCIClient, poolID, compartmentID string, requestedAt time.Time, existing map[string]bool) *poolWatch {
+	return &poolWatch{
+		client:        client,
+		poolID:        poolID,
+		compartmentID: compartmentID,
+		requestedAt:   requestedAt,
+		existing:      existing,
+		states:        make(map[string]string),
+		runningAt:     make(map[string]time.Time),
+	}
+}
+
+// wait polls the pool and its members every interval until the pool is
+// RUNNING with as many running members as its size, then prints the time to
+// RUNNING. It fails if the pool stops or terminates, or if maxWait passes
+// with the pool only partly provisioned.
+func (w *poolWatch) wait(ctx context.Context, interval, maxWait time.Duration) error {
+	ctxWait, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pool, running, err := w.poll(ctxWait)
+		if err != nil {
+			if ctxWait.Err() != nil && ctx.Err() == nil {
+				return fmt.Errorf("timeout waiting for instance pool %s to be RUNNING: %w", w.poolID, ctxWait.Err())
+			}
+			return err
+		}
+		size := 0
+		if pool.Size != nil {
+			size = *pool.Size
+		}
+		switch pool.LifecycleState {
+		case core.InstancePoolLifecycleStateRunning:
+			if running == size {
+				w.printSummary(size)
+				return nil
+			}
+		case core.InstancePoolLifecycleStateStopping, core.InstancePoolLifecycleStateStopped,
+			core.InstancePoolLifecycleStateTerminating, core.InstancePoolLifecycleStateTerminated:
+			return fmt.Errorf("instance pool %s is %s with %d/%d instances running", w.poolID, pool.LifecycleState, running, size)
+		}
+
+		select {
+		case <-ctxWait.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("instance pool %s only partly provisioned after %s: %s with %d/%d instances running",
+				w.poolID, maxWait, pool.LifecycleState, running, size)
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the pool and its members, prints the members whose state
+// changed since the last poll and returns the pool and how many members are
+// running
+func (w *poolWatch) poll(ctx context.Context) (*core.InstancePool, int, error) {
+	pool, err := w.client.GetInstancePool(ctx, w.poolID)
+	if err != nil {
+		return nil, 0, err
+	}
+	members, err := w.client.ListInstancePoolInstances(ctx, w.compartmentID, w.poolID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now().UTC()
+	elapsed := now.Sub(w.requestedAt).Round(time.Second)
+	running := 0
+	for _, m := range members {
+		id, state := derefString(m.Id), derefString(m.State)
+		isRunning := strings.EqualFold(state, string(core.InstanceLifecycleStateRunning))
+		if isRunning {
+			running++
+		}
+		if w.existing[id] {
+			continue
+		}
+		previous, seen := w.states[id]
+		if seen && previous == state {
+			continue
+		}
+		w.states[id] = state
+		if seen {
+			fmt.Printf("  %s (%s): %s → %s [+%s]\n", derefString(m.DisplayName), id, previous, state, elapsed)
+		} else {
+			fmt.Printf("  %s (%s): %s [+%s]\n", derefString(m.DisplayName), id, state, elapsed)
+		}
+		if isRunning {
+			w.runningAt[id] = now
+		}
+	}
+	return pool, running, nil
+}
+
+// printSummary reports how long the pool and its new members took to be RUNNING
+func (w *poolWatch) printSummary(size int) {
+	fmt.Printf("Instance pool is RUNNING with %d/%d instances after %s\n", size, size,
+		time.Since(w.requestedAt).Round(time.Second))
+	if len(w.runningAt) == 0 {
+		return
+	}
+	var ready []time.Duration
+	for _, at := range w.runningAt {
+		ready = append(ready, at.Sub(w.requestedAt))
+	}
+	sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+	var total time.Duration
+	for _, d := range ready {
+		total += d
+	}
+	fmt.Printf("Time to RUNNING for %d new instance(s): p50 %s | mean %s | max %s\n", len(ready),
+		ready[len(ready)/2].Round(time.Second), (total / time.Duration(len(ready))).Round(time.Second),
+		ready[len(ready)-1].Round(time.Second))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+func TestPoolWatchWaitsForEveryMember(t *testing.T) {
+	clock := ocifake.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := ocifake.New(ocifake.WithClock(clock.Now), ocifake.WithTimeline(ocifake.Timeline{Provisioning: time.Minute}))
+	poolID := createFakePool(t, b, 3)
+
+	watch := newPoolWatch(fakeClient(b), poolID, "ocid1.compartment.test", time.Now().UTC(), nil)
+	done := make(chan error, 1)
+	go func() { done <- watch.wait(context.Background(), time.Millisecond, 10*time.Second) }()
+
+	// Let it see the pool provisioning before the members come up
+	for b.Calls(ocifake.OpListInstancePoolInstances) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(watch.runningAt) != 3 {
+		t.Errorf("timed %d members, want 3", len(watch.runningAt))
+	}
+	for id, state := range watch.states {
+		if state != "Running" {
+			t.Errorf("last saw %s %s, want Running", id, state)
+		}
+	}
+}
+
+func TestPoolWatchSkipsExistingMembers(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 2)
+	existing := map[string]bool{}
+	for _, inst := range b.Instances() {
+		existing[*inst.Id] = true
+	}
+	if _, err := b.UpdateInstancePool(context.Background(), core.UpdateInstancePoolRequest{
+		InstancePoolId:            common.String(poolID),
+		UpdateInstancePoolDetails: core.UpdateInstancePoolDetails{Size: common.Int(3)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	watch := newPoolWatch(fakeClient(b), poolID, "ocid1.compartment.test", time.Now().UTC(), existing)
+	if err := watch.wait(context.Background(), time.Millisecond, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if len(watch.runningAt) != 1 {
+		t.Errorf("timed %d members, want only the new one", len(watch.runningAt))
+	}
+}
+
+func TestPoolWatchStopsWhenThePoolTerminates(t *testing.T) {
+	b := ocifake.New(ocifake.WithTimeline(ocifake.Timeline{Provisioning: time.Hour, Terminating: time.Hour}))
+	poolID := createFakePool(t, b, 2)
+	if err := fakeClient(b).TerminateInstancePool(context.Background(), poolID); err != nil {
+		t.Fatal(err)
+	}
+
+	watch := newPoolWatch(fakeClient(b), poolID, "ocid1.compartment.test", time.Now().UTC(), nil)
+	err := watch.wait(context.Background(), time.Millisecond, 10*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "TERMINATING") {
+		t.Errorf("wait = %v, want the pool reported TERMINATING", err)
+	}
+}
+
+func TestPoolWatchTimesOut(t *testing.T) {
+	b := ocifake.New(ocifake.WithTimeline(ocifake.Timeline{Provisioning: time.Hour}))
+	poolID := createFakePool(t, b, 2)
+
+	watch := newPoolWatch(fakeClient(b), poolID, "ocid1.compartment.test", time.Now().UTC(), nil)
+	err := watch.wait(context.Background(), time.Millisecond, 20*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "0/2 instances running") {
+		t.Errorf("wait = %v, want a timeout with 0/2 instances running", err)
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+// RateLimits sets client-side request rates shared by every OCI client the
+// tool makes. Mutating calls (launch, create, update, detach, terminate,
+// delete) and read calls (get, list) draw from separate buckets so status
+// polling cannot starve changes.
+type RateLimits struct {
+	MutatingRPS   float64 `yaml:"mutating_rps"`   // sustained mutating requests per second; 0 disables the limit
+	MutatingBurst int     `yaml:"mutating_burst"` // mutating requests allowed back to back
+	ReadRPS       float64 `yaml:"read_rps"`       // sustained read requests per second; 0 disables the limit
+	ReadBurst     int     `yaml:"read_burst"`     // read requests allowed back to back
+}
+
+// DefaultRateLimits returns limits that stay well below the default tenancy
+// API limits for Compute
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		MutatingRPS:   5,
+		MutatingBurst: 10,
+		ReadRPS:       20,
+		ReadBurst:     20,
+	}
+}
+
+// registerRateLimitFlags adds the rate limit flags to fs and returns the limits they populate
+func registerRateLimitFlags(fs *flag.FlagSet) *RateLimits {
+	l := DefaultRateLimits()
+	fs.Float64Var(&l.MutatingRPS, "write-rps", l.MutatingRPS, "Launch/terminate requests per second across all workers (0 for unlimited)")
+	fs.IntVar(&l.MutatingBurst, "write-burst", l.MutatingBurst, "Launch/terminate requests allowed in a burst")
+	fs.Float64Var(&l.ReadRPS, "read-rps", l.ReadRPS, "Get/list requests per second across all workers (0 for unlimited)")
+	fs.IntVar(&l.ReadBurst, "read-burst", l.ReadBurst, "Get/list requests allowed in a burst")
+	return &l
+}
+
+// tokenBucket is a token bucket rate limiter. Waiters reserve tokens in
+// arrival order, so a burst of callers is released at the configured rate
+// rather than all at once when tokens refill.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a bucket that starts full, or nil if rate is not
+// positive. A nil bucket never blocks.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a token is available or ctx is done
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	if b == nil {
+		return ctx.Err()
+	}
+
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Hand the reserved token back so cancelled callers don't slow everyone else
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// apiLimiter holds the buckets shared by every rate-limited client
+type apiLimiter struct {
+	mutating *tokenBucket
+	read     *tokenBucket
+}
+
+// newAPILimiter creates the mutating and read buckets for limits
+func newAPILimiter(limits RateLimits) *apiLimiter {
+	return &apiLimiter{
+		mutating: newTokenBucket(limits.MutatingRPS, limits.MutatingBurst),
+		read:     newTokenBucket(limits.ReadRPS, limits.ReadBurst),
+	}
+}
+
+// rateLimitedCompute wraps a ComputeAPI so every call waits for a token from
+// the shared mutating or read bucket
+type rateLimitedCompute struct {
+	next ComputeAPI
+	*apiLimiter
+}
+
+// newRateLimitedCompute wraps client with limiter
+func newRateLimitedCompute(client ComputeAPI, limiter *apiLimiter) ComputeAPI {
+	return &rateLimitedCompute{next: client, apiLimiter: limiter}
+}
+
+func (c *rateLimitedCompute) LaunchInstance(ctx context.Context, request core.LaunchInstanceRequest) (core.LaunchInstanceResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.LaunchInstanceResponse{}, err
+	}
+	return c.next.LaunchInstance(ctx, request)
+}
+
+func (c *rateLimitedCompute) GetInstance(ctx context.Context, request core.GetInstanceRequest) (core.GetInstanceResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.GetInstanceResponse{}, err
+	}
+	return c.next.GetInstance(ctx, request)
+}
+
+func (c *rateLimitedCompute) ListInstances(ctx context.Context, request core.ListInstancesRequest) (core.ListInstancesResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListInstancesResponse{}, err
+	}
+	return c.next.ListInstances(ctx, request)
+}
+
+func (c *rateLimitedCompute) TerminateInstance(ctx context.Context, request core.TerminateInstanceRequest) (core.TerminateInstanceResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.TerminateInstanceResponse{}, err
+	}
+	return c.next.TerminateInstance(ctx, request)
+}
+
+func (c *rateLimitedCompute) ListBootVolumeAttachments(ctx context.Context, request core.ListBootVolumeAttachmentsRequest) (core.ListBootVolumeAttachmentsResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListBootVolumeAttachmentsResponse{}, err
+	}
+	return c.next.ListBootVolumeAttachments(ctx, request)
+}
+
+func (c *rateLimitedCompute) ListVolumeAttachments(ctx context.Context, request core.ListVolumeAttachmentsRequest) (core.ListVolumeAttachmentsResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListVolumeAttachmentsResponse{}, err
+	}
+	return c.next.ListVolumeAttachments(ctx, request)
+}
+
+// rateLimitedComputeManagement wraps a ComputeManagementAPI so every call
+// waits for a token from the shared mutating or read bucket
+type rateLimitedComputeManagement struct {
+	next ComputeManagementAPI
+	*apiLimiter
+}
+
+// newRateLimitedComputeManagement wraps client with limiter
+func newRateLimitedComputeManagement(client ComputeManagementAPI, limiter *apiLimiter) ComputeManagementAPI {
+	return &rateLimitedComputeManagement{next: client, apiLimiter: limiter}
+}
+
+func (c *rateLimitedComputeManagement) CreateInstanceConfiguration(ctx context.Context, request core.CreateInstanceConfigurationRequest) (core.CreateInstanceConfigurationResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.CreateInstanceConfigurationResponse{}, err
+	}
+	return c.next.CreateInstanceConfiguration(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) ListInstanceConfigurations(ctx context.Context, request core.ListInstanceConfigurationsRequest) (core.ListInstanceConfigurationsResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListInstanceConfigurationsResponse{}, err
+	}
+	return c.next.ListInstanceConfigurations(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) DeleteInstanceConfiguration(ctx context.Context, request core.DeleteInstanceConfigurationRequest) (core.DeleteInstanceConfigurationResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.DeleteInstanceConfigurationResponse{}, err
+	}
+	return c.next.DeleteInstanceConfiguration(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) CreateInstancePool(ctx context.Context, request core.CreateInstancePoolRequest) (core.CreateInstancePoolResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.CreateInstancePoolResponse{}, err
+	}
+	return c.next.CreateInstancePool(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) UpdateInstancePool(ctx context.Context, request core.UpdateInstancePoolRequest) (core.UpdateInstancePoolResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.UpdateInstancePoolResponse{}, err
+	}
+	return c.next.UpdateInstancePool(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) GetInstancePool(ctx context.Context, request core.GetInstancePoolRequest) (core.GetInstancePoolResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.GetInstancePoolResponse{}, err
+	}
+	return c.next.GetInstancePool(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) ListInstancePools(ctx context.Context, request core.ListInstancePoolsRequest) (core.ListInstancePoolsResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListInstancePoolsResponse{}, err
+	}
+	return c.next.ListInstancePools(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) TerminateInstancePool(ctx context.Context, request core.TerminateInstancePoolRequest) (core.TerminateInstancePoolResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.TerminateInstancePoolResponse{}, err
+	}
+	return c.next.TerminateInstancePool(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) ListInstancePoolInstances(ctx context.Context, request core.ListInstancePoolInstancesRequest) (core.ListInstancePoolInstancesResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListInstancePoolInstancesResponse{}, err
+	}
+	return c.next.ListInstancePoolInstances(ctx, request)
+}
+
+func (c *rateLimitedComputeManagement) DetachInstancePoolInstance(ctx context.Context, request core.DetachInstancePoolInstanceRequest) (core.DetachInstancePoolInstanceResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.DetachInstancePoolInstanceResponse{}, err
+	}
+	return c.next.DetachInstancePoolInstance(ctx, request)
+}
+
+// rateLimitedBlockstorage wraps a BlockstorageAPI so every call waits for a
+// token from the shared mutating or read bucket
+type rateLimitedBlockstorage struct {
+	next BlockstorageAPI
+	*apiLimiter
+}
+
+// newRateLimitedBlockstorage wraps client with limiter
+func newRateLimitedBlockstorage(client BlockstorageAPI, limiter *apiLimiter) BlockstorageAPI {
+	return &rateLimitedBlockstorage{next: client, apiLimiter: limiter}
+}
+
+func (c *rateLimitedBlockstorage) ListBootVolumes(ctx context.Context, request core.ListBootVolumesRequest) (core.ListBootVolumesResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListBootVolumesResponse{}, err
+	}
+	return c.next.ListBootVolumes(ctx, request)
+}
+
+func (c *rateLimitedBlockstorage) DeleteBootVolume(ctx context.Context, request core.DeleteBootVolumeRequest) (core.DeleteBootVolumeResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.DeleteBootVolumeResponse{}, err
+	}
+	return c.next.DeleteBootVolume(ctx, request)
+}
+
+func (c *rateLimitedBlockstorage) ListVolumes(ctx context.Context, request core.ListVolumesRequest) (core.ListVolumesResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return core.ListVolumesResponse{}, err
+	}
+	return c.next.ListVolumes(ctx, request)
+}
+
+func (c *rateLimitedBlockstorage) DeleteVolume(ctx context.Context, request core.DeleteVolumeRequest) (core.DeleteVolumeResponse, error) {
+	if err := c.mutating.Wait(ctx); err != nil {
+		return core.DeleteVolumeResponse{}, err
+	}
+	return c.next.DeleteVolume(ctx, request)
+}
+
+// rateLimitedIdentity wraps an IdentityAPI so every call waits for a token
+// from the shared read bucket
+type rateLimitedIdentity struct {
+	next IdentityAPI
+	*apiLimiter
+}
+
+// newRateLimitedIdentity wraps client with limiter
+func newRateLimitedIdentity(client IdentityAPI, limiter *apiLimiter) IdentityAPI {
+	return &rateLimitedIdentity{next: client, apiLimiter: limiter}
+}
+
+func (c *rateLimitedIdentity) ListCompartments(ctx context.Context, request identity.ListCompartmentsRequest) (identity.ListCompartmentsResponse, error) {
+	if err := c.read.Wait(ctx); err != nil {
+		return identity.ListCompartmentsResponse{}, err
+	}
+	return c.next.ListCompartments(ctx, request)
+}
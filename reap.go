@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// Resource kinds the reap command terminates
+const (
+	reapInstances = "instance"
+	reapPools     = "instance-pool"
+)
+
+// ReapPolicy decides which expired resources the reap command terminates and
+// where it records them
+type ReapPolicy struct {
+	ExcludeTags []string      `yaml:"exclude_tags,omitempty"` // key or key=value freeform tags that protect a resource, besides keepTag
+	AuditLog    string        `yaml:"audit_log,omitempty"`    // JSON lines file every termination is appended to
+	Interval    time.Duration `yaml:"interval,omitempty"`     // time between passes; 0 reaps once and exits
+}
+
+// DefaultReapPolicy returns the policy used when neither flags nor the config set one
+func DefaultReapPolicy() ReapPolicy {
+	return ReapPolicy{AuditLog: "reap-audit.jsonl"}
+}
+
+// excludes reports whether a resource with these tags is protected from
+// reaping, by keepTag or by one of the exclusion tags
+func (p ReapPolicy) excludes(tags map[string]string) bool {
+	if _, ok := tags[keepTag]; ok {
+		return true
+	}
+	return hasAnyTag(tags, p.ExcludeTags)
+}
+
+// reapItem is a resource whose expiry has passed
+type reapItem struct {
+	Kind      string
+	ID        string
+	Name      string
+	RunID     string
+	ExpiresAt time.Time
+}
+
+// reapRecord is one line of the audit log
+type reapRecord struct {
+	Time          time.Time `json:"time"`
+	Kind          string    `json:"kind"`
+	ID            string    `json:"id"`
+	Name          string    `json:"name,omitempty"`
+	CompartmentID string    `json:"compartment_id"`
+	RunID         string    `json:"run_id,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Result        string    `json:"result"` // terminated or failed
+	Error         string    `json:"error,omitempty"`
+}
+
+// runReap implements the reap command
+func runReap(ctx context.Context, args []string) error {
+	fs := newFlagSet("reap", "", "Terminate the instances and instance pools whose "+expiresAtTag+" tag has passed,\nonce or every -interval, and record each one in an audit log.")
+	var (
+		compartment = fs.String("compartment", "", "Compartment ID to reap (required unless set in -config)")
+		excludeTags = fs.String("exclude-tag", "", "Comma-separated freeform tags, as key or key=value, that protect a resource from reaping (in addition to "+keepTag+")")
+		auditLog    = fs.String("audit-log", "reap-audit.jsonl", "JSON lines file each termination is appended to")
+		interval    = fs.Duration("interval", 0, "Reap again this long after each pass until interrupted (0 reaps once)")
+		parallel    = fs.Int("parallel", 10, "Number of parallel termination operations")
+		dryRun      = fs.Bool("dry-run", false, "Show what has expired without terminating it")
+	)
+	shared := registerCommonFlags(fs, "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := shared.load()
+	if err != nil {
+		return err
+	}
+	set := flagsSet(fs)
+	fromConfig(set, "compartment", compartment, config.CompartmentID)
+	if *compartment == "" {
+		return usageErrorf(fs, "compartment flag is required")
+	}
+	if *parallel < 1 {
+		return usageErrorf(fs, "parallel must be at least 1")
+	}
+
+	// Flags override the reap section of the config
+	policy := config.Reap
+	if set["exclude-tag"] {
+		policy.ExcludeTags = splitList(*excludeTags)
+	}
+	if set["audit-log"] {
+		policy.AuditLog = *auditLog
+	}
+	if set["interval"] {
+		policy.Interval = *interval
+	}
+	if policy.AuditLog == "" {
+		return usageErrorf(fs, "audit-log must not be empty")
+	}
+	if policy.Interval < 0 {
+		return usageErrorf(fs, "interval must not be negative")
+	}
+
+	client, err := NewOCIClient(config)
+	if err != nil {
+		return err
+	}
+
+	if policy.Interval == 0 {
+		return reapOnce(ctx, client, *compartment, policy, *parallel, *dryRun, config.Retry)
+	}
+
+	// As a loop, a failed pass is reported and retried on the next one; only
+	// an interrupt stops it, which exits as interrupted like any other command
+	fmt.Printf("Reaping %s every %s; press Ctrl-C to stop\n", *compartment, policy.Interval)
+	for {
+		if err := reapOnce(ctx, client, *compartment, policy, *parallel, *dryRun, config.Retry); err != nil && ctx.Err() == nil {
+			fmt.Printf("❌ %v\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped reaping")
+			return ctx.Err()
+		case <-time.After(policy.Interval):
+		}
+	}
+}
+
+// reapOnce finds the expired resources in compartmentID and, unless dryRun,
+// terminates them and appends the outcome to the audit log
+func reapOnce(ctx context.Context, client *OCIClient, compartmentID string, policy ReapPolicy, parallel int, dryRun bool, retry RetryPolicy) error {
+	now := time.Now()
+	fmt.Printf("\n[%s] Looking for expired resources in %s...\n", now.UTC().Format(time.RFC3339), compartmentID)
+	items, err := findExpired(ctx, client, compartmentID, policy, now, retry)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("Nothing has expired")
+		return nil
+	}
+
+	fmt.Printf("%d resource(s) expired:\n", len(items))
+	printReapPreview(items, now)
+	if dryRun {
+		fmt.Println("\nDry run: nothing was terminated")
+		return nil
+	}
+
+	records := terminateExpired(ctx, client, compartmentID, items, parallel, retry)
+	failures := 0
+	for _, record := range records {
+		if record.Result != "terminated" {
+			failures++
+		}
+	}
+	fmt.Printf("\nSummary: %d/%d expired resources terminated\n", len(items)-failures, len(items))
+	if err := appendAuditLog(policy.AuditLog, records); err != nil {
+		return fmt.Errorf("writing audit log %s: %w", policy.AuditLog, err)
+	}
+	fmt.Printf("Audit log appended to %s\n", policy.AuditLog)
+	if failures > 0 {
+		return fmt.Errorf("%d of %d expired resources could not be terminated", failures, len(items))
+	}
+	return nil
+}
+
+// findExpired lists the live instance pools and instances whose expiry tag
+// is before now and that no exclusion tag protects, oldest expiry first.
+// Members of a live pool are left to their pool: terminating one on its own
+// would only make the pool launch a replacement.
+func findExpired(ctx context.Context, client *OCIClient, compartmentID string, policy ReapPolicy, now time.Time, retry RetryPolicy) ([]reapItem, error) {
+	expired := func(kind, id, name string, tags map[string]string) (reapItem, bool) {
+		value, ok := tags[expiresAtTag]
+		if !ok || policy.excludes(tags) {
+			return reapItem{}, false
+		}
+		expiresAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			fmt.Printf("❌ Skipping %s %s: invalid %s tag %q\n", kind, id, expiresAtTag, value)
+			return reapItem{}, false
+		}
+		if expiresAt.After(now) {
+			return reapItem{}, false
+		}
+		return reapItem{Kind: kind, ID: id, Name: name, RunID: tags[runIDTag], ExpiresAt: expiresAt}, true
+	}
+
+	pools, err := client.ListInstancePools(ctx, compartmentID)
+	if err != nil {
+		return nil, err
+	}
+	var items []reapItem
+	inPool := make(map[string]bool)
+	for _, p := range pools {
+		switch p.LifecycleState {
+		case core.InstancePoolSummaryLifecycleStateTerminating, core.InstancePoolSummaryLifecycleStateTerminated:
+			continue
+		}
+		if item, ok := expired(reapPools, *p.Id, derefString(p.DisplayName), p.FreeformTags); ok {
+			items = append(items, item)
+		}
+		members, err := client.ListInstancePoolInstances(ctx, compartmentID, *p.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			inPool[derefString(m.Id)] = true
+		}
+	}
+
+	instances, err := selectInstances(ctx, client, compartmentID, false, instanceSelector{}, retry)
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range instances {
+		if inPool[*inst.Id] {
+			continue
+		}
+		if item, ok := expired(reapInstances, *inst.Id, derefString(inst.DisplayName), inst.FreeformTags); ok {
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].ExpiresAt.Before(items[j].ExpiresAt) })
+	return items, nil
+}
+
+// printReapPreview lists the expired resources with how long ago they expired
+func printReapPreview(items []reapItem, now time.Time) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  KIND\tNAME\tRUN ID\tEXPIRED\tID")
+	for _, item := range items {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s ago\t%s\n", item.Kind, item.Name, item.RunID, formatAge(now.Sub(item.ExpiresAt)), item.ID)
+	}
+	w.Flush()
+}
+
+// terminateExpired terminates the expired pools, which takes their instances
+// with them, then the expired instances, and returns an audit record for each
+func terminateExpired(ctx context.Context, client *OCIClient, compartmentID string, items []reapItem, parallel int, retry RetryPolicy) []reapRecord {
+	record := func(item reapItem, err error) reapRecord {
+		r := reapRecord{
+			Time:          time.Now().UTC(),
+			Kind:          item.Kind,
+			ID:            item.ID,
+			Name:          item.Name,
+			CompartmentID: compartmentID,
+			RunID:         item.RunID,
+			ExpiresAt:     item.ExpiresAt,
+			Result:        "terminated",
+		}
+		if err != nil {
+			r.Result = "failed"
+			r.Error = err.Error()
+		}
+		return r
+	}
+
+	var records []reapRecord
+	var instanceIDs []string
+	byID := make(map[string]reapItem)
+	for _, item := range items {
+		if item.Kind == reapInstances {
+			instanceIDs = append(instanceIDs, item.ID)
+			byID[item.ID] = item
+			continue
+		}
+		err := client.TerminateInstancePool(ctx, item.ID)
+		if err != nil {
+			fmt.Printf("❌ Failed to terminate instance pool %s: %v\n", item.ID, err)
+		} else {
+			fmt.Printf("✓ Termination accepted for instance pool %s and its instances\n", item.ID)
+		}
+		records = append(records, record(item, err))
+	}
+	if len(instanceIDs) > 0 {
+		for _, result := range terminateInstances(ctx, client.ComputeClient, instanceIDs, parallel, 0, false, retry) {
+			records = append(records, record(byID[result.InstanceID], result.Error))
+		}
+	}
+	return records
+}
+
+// appendAuditLog appends records to the audit log as JSON lines, creating it
+// if needed
+func appendAuditLog(path string, records []reapRecord) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// runResume implements the resume command
+func runResume(ctx context.Context, args []string) error {
+	fs := newFlagSet("resume", "", "Pick up an interrupted launch run from its manifest: wait for the instances that were\nstill starting and launch the ones that are missing.")
+	var (
+		manifestFile     = fs.String("file", "instances.json", "Run manifest written by launch; updated in place")
+		parallel         = fs.Int("parallel", 0, "Maximum number of instances being created at once (default: as launched)")
+		waveSize         = fs.Int("wave-size", 0, "Launch the missing instances in waves of this many (default: as launched)")
+		terminateOnAbort = fs.Bool("terminate-on-interrupt", false, "On Ctrl-C, terminate every instance the run launched instead of leaving them for another resume")
+	)
+	shared := registerCommonFlags(fs, "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	manifest, err := readManifest(*manifestFile)
+	if err != nil {
+		return fmt.Errorf("reading run manifest: %w", err)
+	}
+	request := manifest.Request
+
+	// Credentials, endpoint, retries and rate limits come from the config
+	// the run was launched with unless another one is given
+	set := flagsSet(fs)
+	if !set["config"] {
+		*shared.configFile = request.ConfigFile
+	}
+	config, err := shared.load()
+	if err != nil {
+		return err
+	}
+	if !set["parallel"] {
+		*parallel = request.Parallel
+	}
+	if !set["wave-size"] {
+		*waveSize = request.WaveSize
+	}
+
+	// Sort the recorded instances into those still on their way to RUNNING
+	// and the indexes that never got an instance, or lost it
+	byIndex := make(map[int]ManifestInstance)
+	for _, inst := range manifest.Instances {
+		byIndex[inst.Index] = inst
+	}
+	var inFlight []ManifestInstance
+	var missing []InstanceConfig
+	running := 0
+	for index := 1; index <= request.Instances; index++ {
+		inst, ok := byIndex[index]
+		switch {
+		case !ok || inst.ID == "" || inst.gone():
+			cfg := manifest.instanceConfig(index)
+			if ok && inst.gone() {
+				// The index's instance was terminated, so the replacement
+				// needs a token of its own
+				cfg.RetryToken = retryToken(cfg.RetryToken, inst.ID)
+			}
+			missing = append(missing, cfg)
+		case inst.State == string(core.InstanceLifecycleStateRunning):
+			running++
+		default:
+			inFlight = append(inFlight, inst)
+		}
+	}
+
+	fmt.Printf("Resuming run %s: %d/%d running, %d in flight, %d to launch\n",
+		manifest.RunID, running, request.Instances, len(inFlight), len(missing))
+	if len(inFlight) == 0 && len(missing) == 0 {
+		fmt.Println("Nothing to resume")
+		return nil
+	}
+
+	client, err := NewOCIClient(config)
+	if err != nil {
+		return err
+	}
+	api := client.ComputeClient
+
+	manifest.FinishedAt = nil
+	manifest.ResumedAt = append(manifest.ResumedAt, time.Now().UTC())
+	journal, err := newRunJournal(*manifestFile, manifest)
+	if err != nil {
+		return fmt.Errorf("writing run manifest: %w", err)
+	}
+	tally := newLaunchTally()
+
+	if len(inFlight) > 0 {
+		fmt.Printf("Waiting for %d instance(s) launched before the interruption...\n", len(inFlight))
+		launched := make([]InstanceResult, len(inFlight))
+		for i, inst := range inFlight {
+			launched[i] = resumedResult(inst)
+		}
+		awaitLaunched(ctx, api, launched, *parallel, config.Retry, journal, tally)
+	}
+
+	// An instance whose launch was accepted but never journaled is found by
+	// name and adopted rather than launched a second time
+	if len(missing) > 0 {
+		adopted, rest, err := adoptExisting(ctx, api, request.CompartmentID, manifest.RunID, missing, config.Retry)
+		if err != nil {
+			return err
+		}
+		awaitLaunched(ctx, api, adopted, *parallel, config.Retry, journal, tally)
+		missing = rest
+	}
+	if len(missing) > 0 {
+		fmt.Printf("Launching %d missing instance(s)...\n", len(missing))
+		launchWaves(ctx, api, planWaves(missing, *waveSize), *parallel, config.Retry, journal, tally)
+	}
+
+	running += tally.succeeded
+	fmt.Printf("\nSummary: %d/%d instances running\n", running, request.Instances)
+	tally.print()
+
+	if err := journal.finish(); err != nil {
+		return fmt.Errorf("writing run manifest: %w", err)
+	}
+	fmt.Printf("Run manifest written to %s\n", *manifestFile)
+	if ctx.Err() != nil {
+		return interruptedRun(ctx, api, journal, *terminateOnAbort, config.Retry)
+	}
+	if running < request.Instances {
+		return fmt.Errorf("%d of %d instances are not running", request.Instances-running, request.Instances)
+	}
+	return nil
+}
+
+// resumedResult rebuilds the launch result recorded in a manifest entry
+func resumedResult(inst ManifestInstance) InstanceResult {
+	result := InstanceResult{
+		Index:        inst.Index,
+		InstanceName: inst.DisplayName,
+		InstanceID:   inst.ID,
+		Attempts:     inst.Attempts,
+		Shape:        inst.Shape,
+		Fallbacks:    inst.Fallbacks,
+		State:        core.InstanceLifecycleStateEnum(inst.State),
+	}
+	if inst.LaunchedAt != nil {
+		result.LaunchStarted = *inst.LaunchedAt
+	}
+	if inst.Placement != nil {
+		result.Placement = *inst.Placement
+	}
+	return result
+}
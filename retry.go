@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+// RetryPolicy controls how transient OCI errors are retried
+type RetryPolicy struct {
+	MaxAttempts int           `yaml:"max_attempts"` // total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration `yaml:"base_delay"`   // backoff before the first retry, doubled on each attempt
+	MaxDelay    time.Duration `yaml:"max_delay"`    // cap on a single backoff; 0 means no cap
+	OpTimeout   time.Duration `yaml:"op_timeout"`   // budget for one operation across all attempts; 0 means no limit
+}
+
+// DefaultRetryPolicy returns the policy used when neither flags nor the config set one
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+		OpTimeout:   5 * time.Minute,
+	}
+}
+
+// registerRetryFlags adds the retry flags to fs and returns the policy they populate
+func registerRetryFlags(fs *flag.FlagSet) *RetryPolicy {
+	p := DefaultRetryPolicy()
+	fs.IntVar(&p.MaxAttempts, "max-attempts", p.MaxAttempts, "Maximum attempts per API call for retryable errors (1 disables retries)")
+	fs.DurationVar(&p.BaseDelay, "retry-base-delay", p.BaseDelay, "Initial retry backoff, doubled on each attempt")
+	fs.DurationVar(&p.MaxDelay, "retry-max-delay", p.MaxDelay, "Maximum backoff between retries (0 for no cap)")
+	fs.DurationVar(&p.OpTimeout, "op-timeout", p.OpTimeout, "Deadline for a single API operation including retries (0 for none)")
+	return &p
+}
+
+// backoff returns the delay before the given retry (1 for the first retry),
+// using exponential backoff with full jitter. A MaxDelay of 0 leaves the
+// backoff uncapped.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 1; i < retry && ceiling < math.MaxInt64/2; i++ {
+		if p.MaxDelay > 0 && ceiling >= p.MaxDelay {
+			break
+		}
+		ceiling *= 2
+	}
+	if p.MaxDelay > 0 && ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// isRetryable reports whether err is a transient failure worth retrying:
+// throttling, 5xx responses (which include out-of-capacity), resources in a
+// transitional state, and network timeouts
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if common.IsNetworkError(err) {
+		return true
+	}
+	se, ok := serviceError(err)
+	if !ok {
+		return false
+	}
+	switch se.GetHTTPStatusCode() {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		return se.GetCode() == "IncorrectState"
+	}
+	return isOutOfCapacity(err)
+}
+
+// serviceError finds the OCI service error in err's chain. Unlike
+// common.IsServiceError it sees through errors wrapped with %w.
+func serviceError(err error) (common.ServiceError, bool) {
+	var se common.ServiceError
+	ok := errors.As(err, &se)
+	return se, ok
+}
+
+// isOutOfCapacity reports whether err is OCI's "Out of host capacity" error
+func isOutOfCapacity(err error) bool {
+	se, ok := serviceError(err)
+	if !ok {
+		return false
+	}
+	return strings.Contains(strings.ToLower(se.GetMessage()), "out of host capacity") ||
+		strings.Contains(strings.ToLower(se.GetMessage()), "out of capacity")
+}
+
+// isLimitExceeded reports whether err is a service limit or compartment quota
+// error, which no amount of retrying the same shape will fix
+func isLimitExceeded(err error) bool {
+	se, ok := serviceError(err)
+	if !ok {
+		return false
+	}
+	return se.GetCode() == "LimitExceeded" || se.GetCode() == "QuotaExceeded"
+}
+
+// isNotFound reports whether err is a 404, as GetInstance returns once a
+// terminated instance has been purged
+func isNotFound(err error) bool {
+	se, ok := serviceError(err)
+	return ok && se.GetHTTPStatusCode() == http.StatusNotFound
+}
+
+// describeError returns a one-line summary of err; SDK service errors are
+// otherwise several lines long
+func describeError(err error) string {
+	if se, ok := serviceError(err); ok {
+		return fmt.Sprintf("%d %s: %s", se.GetHTTPStatusCode(), se.GetCode(), se.GetMessage())
+	}
+	return err.Error()
+}
+
+// retryToken derives an OCI retry token from parts, such as a run ID and an
+// instance index. The same parts always give the same token, so a create
+// that is repeated after a lost response, a crash or a rerun of the same run
+// returns the resource made the first time instead of creating another. The
+// service remembers tokens for 24 hours.
+func retryToken(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:]) // 64 characters, the longest token OCI accepts
+}
+
+// withRetry runs op until it succeeds, fails with a non-retryable error, runs
+// out of attempts or exceeds the policy's OpTimeout. It returns the number of
+// attempts made alongside the final error.
+func withRetry(ctx context.Context, policy RetryPolicy, name string, op func(ctx context.Context) error) (int, error) {
+	return withRetryWhen(ctx, policy, name, isRetryable, op)
+}
+
+// withRetryWhen is withRetry with a caller-supplied test for retryable errors
+func withRetryWhen(ctx context.Context, policy RetryPolicy, name string, retryable func(error) bool, op func(ctx context.Context) error) (int, error) {
+	if policy.OpTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.OpTimeout)
+		defer cancel()
+	}
+
+	attempts := 0
+	for {
+		attempts++
+		err := op(ctx)
+		if err == nil {
+			return attempts, nil
+		}
+		if !retryable(err) || attempts >= policy.MaxAttempts {
+			if attempts > 1 {
+				err = fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+			}
+			return attempts, err
+		}
+
+		delay := policy.backoff(attempts)
+		fmt.Printf("↻ %s: retrying in %s (attempt %d/%d): %s\n",
+			name, delay.Round(time.Millisecond), attempts+1, policy.MaxAttempts, describeError(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, fmt.Errorf("giving up after %d attempts: %w", attempts, errors.Join(err, ctx.Err()))
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffStaysUnderCeiling(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	ceilings := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 60: 5 * time.Second}
+	for retry, ceiling := range ceilings {
+		for i := 0; i < 100; i++ {
+			if d := p.backoff(retry); d < 0 || d > ceiling {
+				t.Fatalf("backoff(%d) = %s, want 0-%s", retry, d, ceiling)
+			}
+		}
+	}
+}
+
+func TestBackoffZeroMaxDelayIsUncapped(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second}
+	var longest time.Duration
+	for i := 0; i < 100; i++ {
+		longest = max(longest, p.backoff(10))
+	}
+	if longest <= 30*time.Second {
+		t.Errorf("longest of 100 backoffs for retry 10 = %s, want some above 30s of the 512s ceiling", longest)
+	}
+	if d := p.backoff(200); d < 0 {
+		t.Errorf("backoff(200) = %s, want the doubling to stop short of overflow", d)
+	}
+}
+
+func TestBackoffZeroBaseDelay(t *testing.T) {
+	if d := (RetryPolicy{MaxDelay: time.Second}).backoff(3); d != 0 {
+		t.Errorf("backoff with no base delay = %s, want 0", d)
+	}
+}
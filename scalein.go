@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// Scale-in victim orders
+const (
+	scaleInOldest  = "oldest"  // the longest-running instances go first
+	scaleInNewest  = "newest"  // the most recently launched instances go first
+	scaleInBalance = "balance" // instances in the fault domain with the most members go first, oldest first within it
+)
+
+var scaleInOrders = []string{scaleInOldest, scaleInNewest, scaleInBalance}
+
+// ScaleInPolicy decides which instances pool scale removes when it shrinks a
+// pool. With neither an order nor protected tags the service picks them.
+type ScaleInPolicy struct {
+	Order         string   `yaml:"order,omitempty"`          // oldest, newest or balance; oldest when only protected tags are set
+	ProtectedTags []string `yaml:"protected_tags,omitempty"` // key or key=value freeform tags of instances never removed
+}
+
+// enabled reports whether the tool chooses the instances to remove
+func (p ScaleInPolicy) enabled() bool {
+	return p.Order != "" || len(p.ProtectedTags) > 0
+}
+
+// validate checks the order is known
+func (p ScaleInPolicy) validate() error {
+	for _, order := range scaleInOrders {
+		if p.Order == "" || p.Order == order {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown scale-in order %q: use %s", p.Order, strings.Join(scaleInOrders, ", "))
+}
+
+// pickVictims returns count members to remove in the policy's order, leaving
+// out protected ones. tags holds each member's freeform tags by ID.
+func (p ScaleInPolicy) pickVictims(members []core.InstanceSummary, tags map[string]map[string]string, count int) ([]core.InstanceSummary, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+	var candidates []core.InstanceSummary
+	for _, m := range members {
+		if !hasAnyTag(tags[derefString(m.Id)], p.ProtectedTags) {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) < count {
+		return nil, fmt.Errorf("cannot remove %d instances: only %d of %d are not protected", count, len(candidates), len(members))
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return summaryCreated(candidates[i]).Before(summaryCreated(candidates[j]))
+	})
+	switch p.Order {
+	case scaleInNewest:
+		for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		}
+	case scaleInBalance:
+		return pickFromFullestFaultDomains(members, candidates, count), nil
+	}
+	return candidates[:count], nil
+}
+
+// pickFromFullestFaultDomains picks count candidates one at a time, each from
+// the availability and fault domain that has the most pool members left,
+// taking the oldest candidate there. Protected members count towards a fault
+// domain's load even though they are never picked.
+func pickFromFullestFaultDomains(members, candidates []core.InstanceSummary, count int) []core.InstanceSummary {
+	domain := func(m core.InstanceSummary) string {
+		return derefString(m.AvailabilityDomain) + "/" + derefString(m.FaultDomain)
+	}
+	load := make(map[string]int)
+	for _, m := range members {
+		load[domain(m)]++
+	}
+	remaining := make(map[string][]core.InstanceSummary)
+	for _, c := range candidates {
+		remaining[domain(c)] = append(remaining[domain(c)], c)
+	}
+
+	var victims []core.InstanceSummary
+	for len(victims) < count {
+		fullest := ""
+		for _, d := range sortedKeys(load) {
+			if len(remaining[d]) > 0 && (fullest == "" || load[d] > load[fullest]) {
+				fullest = d
+			}
+		}
+		victims = append(victims, remaining[fullest][0])
+		remaining[fullest] = remaining[fullest][1:]
+		load[fullest]--
+	}
+	return victims
+}
+
+// scaleIn shrinks the pool from current to target instances by detaching and
+// terminating the instances the policy picks, instead of leaving the choice
+// to the service. Only RUNNING members are picked, since the others are
+// already on their way in or out. The instances go in steps of at most
+// maxStep, one at a time with the pool back to RUNNING before each; with wait
+// it then follows the pool until every remaining member is running.
+func scaleIn(ctx context.Context, client *OCIClient, config *Config, instancePoolID string, policy ScaleInPolicy,
+	current, target, maxStep int, wait bool, waitTimeout time.Duration) error {
+	count := current - target
+	if count <= 0 {
+		return nil
+	}
+	members, err := client.ListInstancePoolInstances(ctx, config.CompartmentID, instancePoolID)
+	if err != nil {
+		return err
+	}
+	var running []core.InstanceSummary
+	for _, m := range members {
+		if strings.EqualFold(derefString(m.State), string(core.InstanceLifecycleStateRunning)) {
+			running = append(running, m)
+		}
+	}
+	var tags map[string]map[string]string
+	if len(policy.ProtectedTags) > 0 {
+		// Pool member summaries carry no tags, so look them up in the compartment
+		instances, err := selectInstances(ctx, client, config.CompartmentID, false, instanceSelector{}, config.Retry)
+		if err != nil {
+			return err
+		}
+		tags = make(map[string]map[string]string, len(instances))
+		for _, inst := range instances {
+			tags[*inst.Id] = inst.FreeformTags
+		}
+	}
+	if policy.Order == "" {
+		policy.Order = scaleInOldest
+	}
+
+	victims, err := policy.pickVictims(running, tags, count)
+	if err != nil {
+		return fmt.Errorf("%w (%d of the pool's %d members are RUNNING)", err, len(running), len(members))
+	}
+	fmt.Printf("Removing %d instance(s), %s first:\n", len(victims), policy.Order)
+	printPoolMemberPreview(victims)
+
+	steps := scaleSteps(current, target, maxStep)
+	removed := 0
+	for i, size := range steps {
+		batch := victims[removed : current-size]
+		if len(steps) > 1 {
+			fmt.Printf("Removing %d instance(s) to scale instance pool %s to %d instances (step %d/%d)...\n",
+				len(batch), instancePoolID, size, i+1, len(steps))
+		}
+		if failures := detachInstances(ctx, client, instancePoolID, batch, true, true, waitTimeout); failures > 0 {
+			return fmt.Errorf("%d of %d instances could not be removed", failures+len(victims)-(current-size), len(victims))
+		}
+		removed = current - size
+	}
+	fmt.Printf("Successfully scaled instance pool to %d instances\n", target)
+
+	if wait {
+		fmt.Println("Waiting for the instance pool to be RUNNING...")
+		remaining, err := client.ListInstancePoolInstances(ctx, config.CompartmentID, instancePoolID)
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]bool, len(remaining))
+		for _, m := range remaining {
+			existing[derefString(m.Id)] = true
+		}
+		watch := newPoolWatch(client, instancePoolID, config.CompartmentID, time.Now().UTC(), existing)
+		return watch.wait(ctx, poolPollInterval, waitTimeout)
+	}
+	return nil
+}
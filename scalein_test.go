@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+// memberStates reports the given pool members in another state, such as a
+// member that is stopping while the rest of the pool runs
+type memberStates struct {
+	*ocifake.Backend
+	states map[string]string
+}
+
+func (m memberStates) ListInstancePoolInstances(ctx context.Context, request core.ListInstancePoolInstancesRequest) (core.ListInstancePoolInstancesResponse, error) {
+	resp, err := m.Backend.ListInstancePoolInstances(ctx, request)
+	for i, item := range resp.Items {
+		if state, ok := m.states[derefString(item.Id)]; ok {
+			resp.Items[i].State = common.String(state)
+		}
+	}
+	return resp, err
+}
+
+func TestPickVictims(t *testing.T) {
+	members := []core.InstanceSummary{
+		member("b", "FAULT-DOMAIN-1", 2),
+		member("a", "FAULT-DOMAIN-1", 1),
+		member("c", "FAULT-DOMAIN-2", 3),
+		member("d", "FAULT-DOMAIN-1", 4),
+	}
+	tags := map[string]map[string]string{
+		"a": {"team": "db"},
+		"c": {"keep": ""},
+	}
+
+	tests := []struct {
+		policy ScaleInPolicy
+		count  int
+		want   []string
+	}{
+		{ScaleInPolicy{Order: scaleInOldest}, 2, []string{"a", "b"}},
+		{ScaleInPolicy{Order: scaleInNewest}, 2, []string{"d", "c"}},
+		{ScaleInPolicy{Order: scaleInBalance}, 2, []string{"a", "b"}},
+		{ScaleInPolicy{Order: scaleInOldest, ProtectedTags: []string{"team=db"}}, 2, []string{"b", "c"}},
+		{ScaleInPolicy{Order: scaleInNewest, ProtectedTags: []string{"keep", "team=web"}}, 3, []string{"d", "b", "a"}},
+		{ScaleInPolicy{Order: scaleInOldest}, 0, nil},
+		{ScaleInPolicy{Order: scaleInOldest}, -2, nil},
+	}
+	for _, tt := range tests {
+		got, err := tt.policy.pickVictims(members, tags, tt.count)
+		if err != nil {
+			t.Errorf("%+v pick %d: %v", tt.policy, tt.count, err)
+			continue
+		}
+		if !reflect.DeepEqual(ids(got), tt.want) {
+			t.Errorf("%+v pick %d = %v, want %v", tt.policy, tt.count, ids(got), tt.want)
+		}
+	}
+}
+
+func TestPickVictimsTooFewUnprotected(t *testing.T) {
+	members := []core.InstanceSummary{member("a", "FAULT-DOMAIN-1", 1), member("b", "FAULT-DOMAIN-1", 2)}
+	tags := map[string]map[string]string{"a": {"team": "db"}}
+	policy := ScaleInPolicy{Order: scaleInOldest, ProtectedTags: []string{"team"}}
+
+	for _, count := range []int{2, 5} {
+		_, err := policy.pickVictims(members, tags, count)
+		if err == nil || !strings.Contains(err.Error(), "only 1 of 2 are not protected") {
+			t.Errorf("pick %d of 2 with 1 protected: err = %v, want a count of the unprotected members", count, err)
+		}
+	}
+}
+
+func TestScaleInPolicyValidate(t *testing.T) {
+	for _, order := range append([]string{""}, scaleInOrders...) {
+		if err := (ScaleInPolicy{Order: order}).validate(); err != nil {
+			t.Errorf("order %q: %v", order, err)
+		}
+	}
+	if err := (ScaleInPolicy{Order: "sideways"}).validate(); err == nil {
+		t.Error("order sideways: want an error")
+	}
+}
+
+func TestScaleInRemovesOnlyRunningMembersInSteps(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 5)
+	stopping := *b.Instances()[0].Id
+	client := fakeClient(b)
+	client.ComputeManagementClient = memberStates{b, map[string]string{stopping: "Stopping"}}
+	config := testPoolConfig(5)
+
+	policy := ScaleInPolicy{Order: scaleInOldest}
+	if err := scaleIn(context.Background(), client, config, poolID, policy, 5, 2, 2, false, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Calls(ocifake.OpDetachInstancePoolInstance); n != 3 {
+		t.Errorf("made %d detach calls, want 3", n)
+	}
+	if n := b.Calls(ocifake.OpUpdateInstancePool); n != 0 {
+		t.Errorf("made %d UpdateInstancePool calls, want the pool shrunk by detaching only", n)
+	}
+	members, err := client.ListInstancePoolInstances(context.Background(), config.CompartmentID, poolID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left := ids(members)
+	if len(left) != 2 || (left[0] != stopping && left[1] != stopping) {
+		t.Errorf("left %v in the pool, want 2 members including %s, which was not RUNNING", left, stopping)
+	}
+}
+
+func TestScaleInWithTooFewRunningMembers(t *testing.T) {
+	b := ocifake.New()
+	poolID := createFakePool(t, b, 3)
+	all := b.Instances()
+	client := fakeClient(b)
+	client.ComputeManagementClient = memberStates{b, map[string]string{*all[0].Id: "Provisioning", *all[1].Id: "Stopping"}}
+
+	err := scaleIn(context.Background(), client, testPoolConfig(3), poolID, ScaleInPolicy{Order: scaleInNewest}, 3, 1, 0, false, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "1 of the pool's 3 members are RUNNING") {
+		t.Errorf("scaleIn = %v, want a refusal naming the 1 RUNNING member", err)
+	}
+	if n := b.Calls(ocifake.OpDetachInstancePoolInstance); n != 0 {
+		t.Errorf("made %d detach calls, want none", n)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+)
+
+// instanceSelector picks instances by display name prefix, freeform tags,
+// creation time and lifecycle state. Empty fields match anything, except that
+// with no states only live instances (not TERMINATING or TERMINATED) match.
+type instanceSelector struct {
+	NamePrefix    string
+	Tags          map[string]string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	States        []core.InstanceLifecycleStateEnum
+}
+
+// empty reports whether the selector has nothing to filter by, so it would
+// match every live instance
+func (s instanceSelector) empty() bool {
+	return s.NamePrefix == "" && len(s.Tags) == 0 && s.CreatedAfter.IsZero() && s.CreatedBefore.IsZero() && len(s.States) == 0
+}
+
+// matches reports whether inst satisfies every part of the selector
+func (s instanceSelector) matches(inst core.Instance) bool {
+	if !strings.HasPrefix(derefString(inst.DisplayName), s.NamePrefix) {
+		return false
+	}
+	if !hasTags(inst.FreeformTags, s.Tags) {
+		return false
+	}
+	created := timeCreated(inst)
+	if !s.CreatedAfter.IsZero() && created.Before(s.CreatedAfter) {
+		return false
+	}
+	if !s.CreatedBefore.IsZero() && !created.Before(s.CreatedBefore) {
+		return false
+	}
+	if len(s.States) == 0 {
+		return inst.LifecycleState != core.InstanceLifecycleStateTerminating &&
+			inst.LifecycleState != core.InstanceLifecycleStateTerminated
+	}
+	for _, state := range s.States {
+		if inst.LifecycleState == state {
+			return true
+		}
+	}
+	return false
+}
+
+// selectorFlags are the command line flags that build an instanceSelector
+// and the compartments it searches
+type selectorFlags struct {
+	namePrefix    *string
+	tags          *string
+	createdAfter  *string
+	createdBefore *string
+	states        *string
+	subtree       *bool
+}
+
+// selectorFlagNames are the flags registerSelectorFlags adds that filter
+// instances. -subtree only widens where they are looked for, so it is not one.
+var selectorFlagNames = []string{"name-prefix", "tag", "created-after", "created-before", "state"}
+
+// registerSelectorFlags adds the instance selector flags to fs
+func registerSelectorFlags(fs *flag.FlagSet) *selectorFlags {
+	return &selectorFlags{
+		namePrefix:    fs.String("name-prefix", "", "Select instances whose display name starts with this, e.g. the -name given to launch"),
+		tags:          fs.String("tag", "", "Select instances with these comma-separated freeform tags as key=value"),
+		createdAfter:  fs.String("created-after", "", "Select instances created at or after this RFC 3339 time, or this long ago (e.g. 6h)"),
+		createdBefore: fs.String("created-before", "", "Select instances created before this RFC 3339 time, or this long ago (e.g. 30m)"),
+		states:        fs.String("state", "", "Select instances in these comma-separated lifecycle states (default: any but TERMINATING and TERMINATED)"),
+		subtree:       fs.Bool("subtree", false, "Also search every compartment beneath -compartment"),
+	}
+}
+
+// given reports whether any filtering selector flag was set on the command line
+func (f *selectorFlags) given(set map[string]bool) bool {
+	for _, name := range selectorFlagNames {
+		if set[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// selector parses the flags into an instanceSelector, taking durations as
+// that long before now
+func (f *selectorFlags) selector(now time.Time) (instanceSelector, error) {
+	var sel instanceSelector
+	var err error
+	sel.NamePrefix = *f.namePrefix
+	if sel.Tags, err = mergeTags(nil, *f.tags); err != nil {
+		return sel, err
+	}
+	if sel.CreatedAfter, err = parseTimeFlag("created-after", *f.createdAfter, now); err != nil {
+		return sel, err
+	}
+	if sel.CreatedBefore, err = parseTimeFlag("created-before", *f.createdBefore, now); err != nil {
+		return sel, err
+	}
+	if !sel.CreatedAfter.IsZero() && !sel.CreatedBefore.IsZero() && !sel.CreatedAfter.Before(sel.CreatedBefore) {
+		return sel, fmt.Errorf("created-after must be earlier than created-before")
+	}
+	for _, name := range splitList(*f.states) {
+		state, ok := core.GetMappingInstanceLifecycleStateEnum(name)
+		if !ok {
+			return sel, fmt.Errorf("invalid state %q: use one of %s", name, strings.Join(core.GetInstanceLifecycleStateEnumStringValues(), ", "))
+		}
+		sel.States = append(sel.States, state)
+	}
+	return sel, nil
+}
+
+// parseTimeFlag parses value as an RFC 3339 time or as a duration before now.
+// An empty value is the zero time.
+func parseTimeFlag(name, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q: use an RFC 3339 time such as 2024-05-01T12:00:00Z or a duration such as 6h", name, value)
+}
+
+// selectInstances lists the instances in compartmentID, and with subtree every
+// compartment beneath it, and returns those sel matches, oldest first
+func selectInstances(ctx context.Context, client *OCIClient, compartmentID string, subtree bool, sel instanceSelector, retry RetryPolicy) ([]core.Instance, error) {
+	compartments := []string{compartmentID}
+	if subtree {
+		children, err := listSubcompartments(ctx, client.IdentityClient, compartmentID, retry)
+		if err != nil {
+			return nil, fmt.Errorf("listing compartments under %s: %w", compartmentID, err)
+		}
+		compartments = append(compartments, children...)
+	}
+
+	// A single state can be filtered by the service
+	request := core.ListInstancesRequest{}
+	if len(sel.States) == 1 {
+		request.LifecycleState = sel.States[0]
+	}
+
+	var selected []core.Instance
+	for _, id := range compartments {
+		request.CompartmentId = common.String(id)
+		request.Page = nil
+		instances, err := listInstances(ctx, client.ComputeClient, request, retry)
+		if err != nil {
+			return nil, fmt.Errorf("listing instances in %s: %w", id, err)
+		}
+		for _, inst := range instances {
+			if sel.matches(inst) {
+				selected = append(selected, inst)
+			}
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return timeCreated(selected[i]).Before(timeCreated(selected[j]))
+	})
+	return selected, nil
+}
+
+// listSubcompartments returns the IDs of every active compartment beneath
+// compartmentID, walking the tree one level at a time since the service only
+// lists a whole subtree from the tenancy root
+func listSubcompartments(ctx context.Context, client IdentityAPI, compartmentID string, retry RetryPolicy) ([]string, error) {
+	var all []string
+	queue := []string{compartmentID}
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		request := identity.ListCompartmentsRequest{
+			CompartmentId:  common.String(parent),
+			LifecycleState: identity.CompartmentLifecycleStateActive,
+		}
+		for {
+			var resp identity.ListCompartmentsResponse
+			_, err := withRetry(ctx, retry, "list compartments", func(ctx context.Context) error {
+				var err error
+				resp, err = client.ListCompartments(ctx, request)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range resp.Items {
+				all = append(all, *c.Id)
+				queue = append(queue, *c.Id)
+			}
+			if resp.OpcNextPage == nil {
+				break
+			}
+			request.Page = resp.OpcNextPage
+		}
+	}
+	return all, nil
+}
+
+// printInstancePreview lists instances one per line with the details needed
+// to check a selection before acting on it
+func printInstancePreview(instances []core.Instance) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tSTATE\tSHAPE\tAVAILABILITY DOMAIN\tCREATED\tID")
+	for _, inst := range instances {
+		created := ""
+		if t := timeCreated(inst); !t.IsZero() {
+			created = t.UTC().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", derefString(inst.DisplayName), inst.LifecycleState,
+			derefString(inst.Shape), derefString(inst.AvailabilityDomain), created, derefString(inst.Id))
+	}
+	w.Flush()
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// ShapeOption is a shape to launch with and, for flexible shapes, the shape
+// config to use. Zero values mean "not set" until resolve fills in defaults.
+type ShapeOption struct {
+	Shape       string                                                           `json:"shape"`
+	Ocpus       float32                                                          `json:"ocpus,omitempty"`
+	MemoryInGBs float32                                                          `json:"memory_in_gbs,omitempty"`
+	Baseline    core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilizationEnum `json:"baseline_ocpu_utilization,omitempty"` // empty for a regular (non-burstable) instance
+	Nvmes       int                                                              `json:"nvmes,omitempty"`
+}
+
+func (s ShapeOption) String() string {
+	if !isFlexShape(s.Shape) {
+		return s.Shape + " (fixed)"
+	}
+	desc := fmt.Sprintf("%s (%g OCPU, %g GB", s.Shape, s.Ocpus, s.MemoryInGBs)
+	if s.Baseline != "" {
+		desc += ", baseline " + baselineNames[s.Baseline]
+	}
+	if s.Nvmes > 0 {
+		desc += fmt.Sprintf(", %d NVMe", s.Nvmes)
+	}
+	return desc + ")"
+}
+
+// shapeFamily holds the limits OCI applies to a flexible shape
+type shapeFamily struct {
+	maxOcpus     float32
+	minGBPerOcpu float32
+	maxGBPerOcpu float32
+	maxMemoryGB  float32
+	burstable    bool // supports a baseline OCPU utilization below 1/1
+	nvme         bool // has local NVMe drives, sized with the OCPU count
+}
+
+// flexFamilies lists the flexible shapes the launcher knows the limits of.
+// Other .Flex shapes are sent as configured and left to the service to check.
+var flexFamilies = map[string]shapeFamily{
+	"VM.Standard.E3.Flex": {maxOcpus: 64, minGBPerOcpu: 1, maxGBPerOcpu: 64, maxMemoryGB: 1024, burstable: true},
+	"VM.Standard.E4.Flex": {maxOcpus: 64, minGBPerOcpu: 1, maxGBPerOcpu: 64, maxMemoryGB: 1024, burstable: true},
+	"VM.Standard.E5.Flex": {maxOcpus: 94, minGBPerOcpu: 1, maxGBPerOcpu: 64, maxMemoryGB: 1049, burstable: true},
+	"VM.Standard3.Flex":   {maxOcpus: 32, minGBPerOcpu: 1, maxGBPerOcpu: 64, maxMemoryGB: 512, burstable: true},
+	"VM.Standard.A1.Flex": {maxOcpus: 80, minGBPerOcpu: 1, maxGBPerOcpu: 64, maxMemoryGB: 512},
+	"VM.Optimized3.Flex":  {maxOcpus: 18, minGBPerOcpu: 1, maxGBPerOcpu: 64, maxMemoryGB: 256},
+	"VM.DenseIO.E4.Flex":  {maxOcpus: 32, minGBPerOcpu: 16, maxGBPerOcpu: 16, maxMemoryGB: 512, nvme: true},
+	"VM.DenseIO.E5.Flex":  {maxOcpus: 48, minGBPerOcpu: 12, maxGBPerOcpu: 12, maxMemoryGB: 576, nvme: true},
+}
+
+// defaultGBPerOcpu is the memory given to a flexible shape when only the OCPU
+// count is set, clamped to the family's range
+const defaultGBPerOcpu = 8
+
+// baselineNames maps baseline utilizations to the short form used by -baseline
+var baselineNames = map[core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilizationEnum]string{
+	core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization8: "1/8",
+	core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization2: "1/2",
+	core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization1: "1/1",
+}
+
+// parseBaseline accepts 1/8, 1/2 or 1/1, or the API's BASELINE_1_8 form
+func parseBaseline(s string) (core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilizationEnum, error) {
+	if s == "" {
+		return "", nil
+	}
+	for value, name := range baselineNames {
+		if s == name || strings.EqualFold(s, string(value)) {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid baseline %q: use 1/8, 1/2 or 1/1", s)
+}
+
+func isFlexShape(shape string) bool {
+	return strings.HasSuffix(shape, ".Flex")
+}
+
+func isGPUShape(shape string) bool {
+	return strings.Contains(shape, ".GPU")
+}
+
+// parseShapes parses a comma-separated priority list of shapes. Each entry is
+// a shape name optionally followed by :ocpus or :ocpus:memoryGB, e.g.
+// "VM.Standard.E4.Flex:2:16,VM.Standard.A1.Flex:4". Anything an entry does not
+// set is taken from defaults, and every entry is then validated against its
+// shape family. defaultsSet reports whether the defaults came from flags the
+// user gave, which then count as explicit when only one shape is listed.
+func parseShapes(s string, defaults ShapeOption, defaultsSet bool) ([]ShapeOption, error) {
+	entries := splitList(s)
+	var shapes []ShapeOption
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("invalid shape %q: use shape, shape:ocpus or shape:ocpus:memoryGB", entry)
+		}
+		option := defaults
+		option.Shape = parts[0]
+		explicit := len(parts) > 1 || (defaultsSet && len(entries) == 1)
+		if len(parts) > 1 {
+			ocpus, err := strconv.ParseFloat(parts[1], 32)
+			if err != nil || ocpus <= 0 {
+				return nil, fmt.Errorf("invalid OCPU count %q for shape %s", parts[1], parts[0])
+			}
+			option.Ocpus = float32(ocpus)
+			option.MemoryInGBs = 0
+		}
+		if len(parts) > 2 {
+			memory, err := strconv.ParseFloat(parts[2], 32)
+			if err != nil || memory <= 0 {
+				return nil, fmt.Errorf("invalid memory %q for shape %s", parts[2], parts[0])
+			}
+			option.MemoryInGBs = float32(memory)
+		}
+
+		resolved, err := option.resolve(explicit)
+		if err != nil {
+			return nil, err
+		}
+		shapes = append(shapes, resolved)
+	}
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("shape flag must name at least one shape")
+	}
+	return shapes, nil
+}
+
+// resolve validates the option against its shape family and fills in
+// defaults. Fixed shapes, including every GPU shape, drop the flexible
+// settings, which is an error if they were asked for explicitly rather than
+// inherited by one entry of a fallback chain.
+func (s ShapeOption) resolve(explicit bool) (ShapeOption, error) {
+	if !isFlexShape(s.Shape) {
+		if explicit {
+			if isGPUShape(s.Shape) {
+				return s, fmt.Errorf("%s is a GPU shape; its OCPUs, memory and GPU count are fixed", s.Shape)
+			}
+			return s, fmt.Errorf("%s is a fixed shape; its OCPUs and memory cannot be configured", s.Shape)
+		}
+		return ShapeOption{Shape: s.Shape}, nil
+	}
+
+	if s.Ocpus == 0 {
+		s.Ocpus = 1
+	}
+	family, known := flexFamilies[s.Shape]
+	if s.MemoryInGBs == 0 {
+		s.MemoryInGBs = s.Ocpus * defaultGBPerOcpu
+		if known {
+			s.MemoryInGBs = clamp(s.MemoryInGBs, s.Ocpus*family.minGBPerOcpu, s.Ocpus*family.maxGBPerOcpu)
+		}
+	}
+	if !known {
+		return s, nil
+	}
+
+	if s.Ocpus > family.maxOcpus {
+		return s, fmt.Errorf("%s supports at most %g OCPUs, got %g", s.Shape, family.maxOcpus, s.Ocpus)
+	}
+	perOcpu := s.MemoryInGBs / s.Ocpus
+	if perOcpu < family.minGBPerOcpu || perOcpu > family.maxGBPerOcpu || s.MemoryInGBs > family.maxMemoryGB {
+		return s, fmt.Errorf("%s needs %g-%g GB per OCPU and at most %g GB, got %g GB for %g OCPUs",
+			s.Shape, family.minGBPerOcpu, family.maxGBPerOcpu, family.maxMemoryGB, s.MemoryInGBs, s.Ocpus)
+	}
+	if s.Baseline != "" && s.Baseline != core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization1 && !family.burstable {
+		return s, fmt.Errorf("%s does not support burstable instances (baseline %s)", s.Shape, baselineNames[s.Baseline])
+	}
+	if s.Nvmes > 0 && !family.nvme {
+		return s, fmt.Errorf("%s has no local NVMe drives", s.Shape)
+	}
+	return s, nil
+}
+
+// shapeConfig returns the launch shape config, or nil for fixed shapes which
+// must not send one
+func (s ShapeOption) shapeConfig() *core.LaunchInstanceShapeConfigDetails {
+	if !isFlexShape(s.Shape) {
+		return nil
+	}
+	config := &core.LaunchInstanceShapeConfigDetails{
+		Ocpus:                   common.Float32(s.Ocpus),
+		MemoryInGBs:             common.Float32(s.MemoryInGBs),
+		BaselineOcpuUtilization: s.Baseline,
+	}
+	if s.Nvmes > 0 {
+		config.Nvmes = common.Int(s.Nvmes)
+	}
+	return config
+}
+
+func clamp(v, lo, hi float32) float32 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// formatShapes joins shapes for display
+func formatShapes(shapes []ShapeOption) string {
+	names := make([]string, len(shapes))
+	for i, s := range shapes {
+		names[i] = s.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// launchTarget is one shape and placement combination to attempt a launch with
+type launchTarget struct {
+	Shape     ShapeOption
+	Placement Placement
+}
+
+func (t launchTarget) String() string {
+	return t.Shape.Shape + " in " + t.Placement.String()
+}
+
+// launchTargets lists every placement for the first shape, then every
+// placement for the next shape, and so on
+func launchTargets(shapes []ShapeOption, placements []Placement) []launchTarget {
+	targets := make([]launchTarget, 0, len(shapes)*len(placements))
+	for _, shape := range shapes {
+		for _, placement := range placements {
+			targets = append(targets, launchTarget{Shape: shape, Placement: placement})
+		}
+	}
+	return targets
+}
+
+// nextShape returns the index of the first target after i with a different
+// shape, or len(targets) if there is none
+func nextShape(targets []launchTarget, i int) int {
+	for j := i + 1; j < len(targets); j++ {
+		if targets[j].Shape.Shape != targets[i].Shape.Shape {
+			return j
+		}
+	}
+	return len(targets)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+func TestParseShapes(t *testing.T) {
+	defaults := ShapeOption{Ocpus: 2}
+	got, err := parseShapes("VM.Standard.E4.Flex:4:32, VM.Standard.A1.Flex, VM.Standard2.1", defaults, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ShapeOption{
+		{Shape: "VM.Standard.E4.Flex", Ocpus: 4, MemoryInGBs: 32},
+		{Shape: "VM.Standard.A1.Flex", Ocpus: 2, MemoryInGBs: 16},
+		{Shape: "VM.Standard2.1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseShapesErrors(t *testing.T) {
+	tests := []struct {
+		shapes      string
+		defaults    ShapeOption
+		defaultsSet bool
+		want        string
+	}{
+		{"", ShapeOption{}, false, "at least one shape"},
+		{"VM.Standard.E4.Flex:1:2:3", ShapeOption{}, false, "invalid shape"},
+		{"VM.Standard.E4.Flex:0", ShapeOption{}, false, "invalid OCPU count"},
+		{"VM.Standard.E4.Flex:2:x", ShapeOption{}, false, "invalid memory"},
+		{"VM.Standard.E4.Flex:65", ShapeOption{}, false, "at most 64 OCPUs"},
+		{"VM.Standard.E4.Flex:2:256", ShapeOption{}, false, "GB per OCPU"},
+		{"VM.Standard2.1:2", ShapeOption{}, false, "fixed shape"},
+		{"VM.GPU3.1:2", ShapeOption{}, false, "GPU shape"},
+		{"VM.Standard2.1", ShapeOption{Ocpus: 2}, true, "fixed shape"},
+	}
+	for _, tt := range tests {
+		_, err := parseShapes(tt.shapes, tt.defaults, tt.defaultsSet)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseShapes(%q) error = %v, want one containing %q", tt.shapes, err, tt.want)
+		}
+	}
+
+	// Flexible settings inherited by a fixed shape in a fallback chain are dropped
+	if _, err := parseShapes("VM.Standard.E4.Flex,VM.Standard2.1", ShapeOption{Ocpus: 2}, true); err != nil {
+		t.Errorf("fixed shape after a flexible one: %v", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		in      ShapeOption
+		want    ShapeOption
+		wantErr string
+	}{
+		{ShapeOption{Shape: "VM.Standard.E4.Flex"}, ShapeOption{Shape: "VM.Standard.E4.Flex", Ocpus: 1, MemoryInGBs: 8}, ""},
+		{ShapeOption{Shape: "VM.DenseIO.E4.Flex", Ocpus: 2}, ShapeOption{Shape: "VM.DenseIO.E4.Flex", Ocpus: 2, MemoryInGBs: 32}, ""},
+		{ShapeOption{Shape: "VM.Custom.Flex", Ocpus: 3}, ShapeOption{Shape: "VM.Custom.Flex", Ocpus: 3, MemoryInGBs: 24}, ""},
+		{ShapeOption{Shape: "VM.Standard.E4.Flex", Baseline: core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization8}, ShapeOption{Shape: "VM.Standard.E4.Flex", Ocpus: 1, MemoryInGBs: 8, Baseline: core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization8}, ""},
+		{ShapeOption{Shape: "VM.Standard.A1.Flex", Baseline: core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization2}, ShapeOption{}, "does not support burstable"},
+		{ShapeOption{Shape: "VM.Standard.E4.Flex", Nvmes: 1}, ShapeOption{}, "no local NVMe"},
+		{ShapeOption{Shape: "VM.Standard.E5.Flex", Ocpus: 64, MemoryInGBs: 1100}, ShapeOption{}, "at most 1049 GB"},
+	}
+	for _, tt := range tests {
+		got, err := tt.in.resolve(true)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("resolve(%+v) error = %v, want one containing %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("resolve(%+v): %v", tt.in, err)
+		} else if got != tt.want {
+			t.Errorf("resolve(%+v) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseBaseline(t *testing.T) {
+	for in, want := range map[string]core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilizationEnum{
+		"":             "",
+		"1/8":          core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization8,
+		"baseline_1_2": core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization2,
+		"1/1":          core.LaunchInstanceShapeConfigDetailsBaselineOcpuUtilization1,
+	} {
+		if got, err := parseBaseline(in); err != nil || got != want {
+			t.Errorf("parseBaseline(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := parseBaseline("1/4"); err == nil {
+		t.Error("parseBaseline(1/4): want an error")
+	}
+}
+
+func TestShapeConfig(t *testing.T) {
+	if c := (ShapeOption{Shape: "VM.Standard2.1"}).shapeConfig(); c != nil {
+		t.Errorf("fixed shape config = %+v, want nil", c)
+	}
+	c := ShapeOption{Shape: "VM.DenseIO.E4.Flex", Ocpus: 8, MemoryInGBs: 128, Nvmes: 1}.shapeConfig()
+	if c == nil || *c.Ocpus != 8 || *c.MemoryInGBs != 128 || c.Nvmes == nil || *c.Nvmes != 1 {
+		t.Errorf("flex shape config = %+v, want 8 OCPU, 128 GB and 1 NVMe", c)
+	}
+}
+
+func TestLaunchTargetsTriesEveryPlacementPerShape(t *testing.T) {
+	shapes := []ShapeOption{{Shape: "A"}, {Shape: "B"}}
+	placements := []Placement{{AvailabilityDomain: "AD-1"}, {AvailabilityDomain: "AD-2"}}
+	targets := launchTargets(shapes, placements)
+
+	var got []string
+	for _, target := range targets {
+		got = append(got, target.String())
+	}
+	want := []string{"A in AD-1", "A in AD-2", "B in AD-1", "B in AD-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("targets = %q, want %q", got, want)
+	}
+	if next := nextShape(targets, 0); next != 2 {
+		t.Errorf("nextShape from the first target = %d, want 2", next)
+	}
+	if next := nextShape(targets, 2); next != len(targets) {
+		t.Errorf("nextShape from the last shape = %d, want %d", next, len(targets))
+	}
+}
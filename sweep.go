@@ -0,0 +1,567 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// Resource kinds the sweep command looks for
+const (
+	sweepInstances       = "instances"
+	sweepInstanceConfigs = "instance-configs"
+	sweepBootVolumes     = "boot-volumes"
+	sweepVolumes         = "volumes"
+)
+
+var sweepKinds = []string{sweepInstances, sweepInstanceConfigs, sweepBootVolumes, sweepVolumes}
+
+// SweepPolicy decides which leftover resources the sweep command removes.
+// A resource is the tool's if it carries the run ID tag or one of the name
+// prefixes, or for a volume if it came from one of the tool's instances; it
+// is left behind if nothing live still uses it.
+type SweepPolicy struct {
+	Kinds          []string      `yaml:"kinds,omitempty"`                      // kinds to sweep; empty means all
+	NamePrefixes   []string      `yaml:"name_prefixes,omitempty"`              // display name prefixes that also mark a resource as the tool's
+	Manifests      []string      `yaml:"manifests,omitempty"`                  // run manifests, or globs of them, whose instances are still in use
+	OlderThan      time.Duration `yaml:"older_than"`                           // only sweep resources at least this old, so a run in progress is left alone
+	InstanceStates []string      `yaml:"instance_states,omitempty"`            // instance lifecycle states that count as left behind
+	StoragePrice   float64       `yaml:"storage_price_per_gb_month,omitempty"` // boot and block volume price used for the cost estimate
+	Delete         bool          `yaml:"delete"`                               // delete without asking, for unattended runs
+}
+
+// DefaultSweepPolicy returns the policy used when neither flags nor the config set one
+func DefaultSweepPolicy() SweepPolicy {
+	return SweepPolicy{
+		OlderThan:      time.Hour,
+		InstanceStates: []string{string(core.InstanceLifecycleStateStopped)},
+		StoragePrice:   0.0425, // balanced block storage: $0.0255/GB plus 10 VPUs at $0.0017/GB
+	}
+}
+
+// owns reports whether a resource with this display name and these tags was
+// created by the tool
+func (p SweepPolicy) owns(name string, tags map[string]string) bool {
+	if _, ok := tags[runIDTag]; ok {
+		return true
+	}
+	for _, prefix := range p.NamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// bootVolumeNameSuffix is what OCI appends to an instance's display name to
+// name its boot volume
+const bootVolumeNameSuffix = " (Boot Volume)"
+
+// sweeps reports whether kind is one of the kinds to sweep
+func (p SweepPolicy) sweeps(kind string) bool {
+	if len(p.Kinds) == 0 {
+		return true
+	}
+	for _, k := range p.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// sweepItem is a resource the sweep found left behind
+type sweepItem struct {
+	Kind    string
+	ID      string
+	Name    string
+	Reason  string // why it counts as left behind
+	Created time.Time
+	SizeGBs int64 // storage it holds, for the cost estimate
+}
+
+// runSweep implements the sweep command
+func runSweep(ctx context.Context, args []string) error {
+	fs := newFlagSet("sweep", "", "Find the instances, instance configurations, boot volumes and detached block volumes\nearlier runs left behind that no live pool or run manifest uses, and delete them.")
+	var (
+		compartment  = fs.String("compartment", "", "Compartment ID to sweep (required unless set in -config)")
+		kinds        = fs.String("kinds", "", "Comma-separated kinds to sweep: "+strings.Join(sweepKinds, ", ")+" (default: all)")
+		namePrefixes = fs.String("name-prefix", "", "Comma-separated display name prefixes that also mark a resource as created by this tool")
+		manifests    = fs.String("manifest", "", "Comma-separated run manifests, or globs of them, whose instances are still in use")
+		olderThan    = fs.Duration("older-than", time.Hour, "Only sweep resources created at least this long ago")
+		states       = fs.String("state", "STOPPED", "Comma-separated instance lifecycle states that count as left behind")
+		storagePrice = fs.Float64("storage-price", 0.0425, "Boot and block volume price per GB-month, for the cost estimate")
+		parallel     = fs.Int("parallel", 10, "Number of parallel delete operations")
+		dryRun       = fs.Bool("dry-run", false, "Show what would be deleted without deleting it")
+		assumeYes    = fs.Bool("yes", false, "Delete without asking for confirmation")
+	)
+	shared := registerCommonFlags(fs, "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := shared.load()
+	if err != nil {
+		return err
+	}
+	set := flagsSet(fs)
+	fromConfig(set, "compartment", compartment, config.CompartmentID)
+	if *compartment == "" {
+		return usageErrorf(fs, "compartment flag is required")
+	}
+	if *parallel < 1 {
+		return usageErrorf(fs, "parallel must be at least 1")
+	}
+
+	// Flags override the sweep section of the config
+	policy := config.Sweep
+	if set["kinds"] {
+		policy.Kinds = splitList(*kinds)
+	}
+	if set["name-prefix"] {
+		policy.NamePrefixes = splitList(*namePrefixes)
+	}
+	if set["manifest"] {
+		policy.Manifests = splitList(*manifests)
+	}
+	if set["older-than"] {
+		policy.OlderThan = *olderThan
+	}
+	if set["state"] {
+		policy.InstanceStates = splitList(*states)
+	}
+	if set["storage-price"] {
+		policy.StoragePrice = *storagePrice
+	}
+	for _, kind := range policy.Kinds {
+		known := false
+		for _, k := range sweepKinds {
+			known = known || k == kind
+		}
+		if !known {
+			return usageErrorf(fs, "unknown kind %q: use %s", kind, strings.Join(sweepKinds, ", "))
+		}
+	}
+	var instanceStates []core.InstanceLifecycleStateEnum
+	for _, name := range policy.InstanceStates {
+		state, ok := core.GetMappingInstanceLifecycleStateEnum(name)
+		if !ok {
+			return usageErrorf(fs, "invalid state %q: use one of %s", name, strings.Join(core.GetInstanceLifecycleStateEnumStringValues(), ", "))
+		}
+		instanceStates = append(instanceStates, state)
+	}
+	inManifest, err := manifestInstanceIDs(policy.Manifests)
+	if err != nil {
+		return err
+	}
+
+	client, err := NewOCIClient(config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Sweeping %s for resources left behind by earlier runs...\n", *compartment)
+	items, err := findLeftovers(ctx, client, *compartment, policy, instanceStates, inManifest, config.Retry)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		fmt.Println("Nothing left behind")
+		return nil
+	}
+
+	fmt.Printf("%d resource(s) left behind:\n", len(items))
+	printSweepPreview(items, policy.StoragePrice, time.Now())
+	if *dryRun {
+		fmt.Println("\nDry run: nothing was deleted")
+		return nil
+	}
+	switch {
+	case *assumeYes:
+	case policy.Delete:
+		fmt.Println("\nDeleting as the sweep policy in the config allows")
+	case !confirm(fmt.Sprintf("\nDelete these %d resource(s)?", len(items))):
+		fmt.Println("Nothing was deleted")
+		return nil
+	}
+
+	failures := deleteLeftovers(ctx, client, items, *parallel, config.Retry)
+	fmt.Printf("\nSummary: %d/%d resources deleted\n", len(items)-failures, len(items))
+	if failures > 0 {
+		return fmt.Errorf("%d of %d resources could not be deleted", failures, len(items))
+	}
+	return nil
+}
+
+// manifestInstanceIDs returns the IDs of the instances recorded in the given
+// run manifests or OCID lists. Each entry may be a glob; one that matches
+// nothing is an error, so a mistyped path cannot expose a run to the sweep.
+func manifestInstanceIDs(patterns []string) (map[string]bool, error) {
+	ids := make(map[string]bool)
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest pattern %q: %w", pattern, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no run manifest matches %s", pattern)
+		}
+		for _, file := range files {
+			instanceIDs, err := readInstancesFromFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("reading run manifest %s: %w", file, err)
+			}
+			for _, id := range instanceIDs {
+				ids[id] = true
+			}
+		}
+	}
+	return ids, nil
+}
+
+// findLeftovers lists the resources of each kind policy sweeps that the tool
+// created, that are old enough and that no live pool or manifest uses,
+// oldest first
+func findLeftovers(ctx context.Context, client *OCIClient, compartmentID string, policy SweepPolicy,
+	instanceStates []core.InstanceLifecycleStateEnum, inManifest map[string]bool, retry RetryPolicy) ([]sweepItem, error) {
+	cutoff := time.Now().Add(-policy.OlderThan)
+	oldEnough := func(created time.Time) bool {
+		return policy.OlderThan <= 0 || (!created.IsZero() && !created.After(cutoff))
+	}
+
+	// Pools that are not on their way out keep their configuration and
+	// members in use
+	var livePools []core.InstancePoolSummary
+	if policy.sweeps(sweepInstances) || policy.sweeps(sweepInstanceConfigs) {
+		pools, err := client.ListInstancePools(ctx, compartmentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pools {
+			switch p.LifecycleState {
+			case core.InstancePoolSummaryLifecycleStateTerminating, core.InstancePoolSummaryLifecycleStateTerminated:
+				continue
+			}
+			livePools = append(livePools, p)
+		}
+	}
+
+	// Boot volumes give both the unattached volumes and the storage each
+	// instance still holds
+	var volumes []core.BootVolume
+	var attachedTo map[string]string
+	if policy.sweeps(sweepInstances) || policy.sweeps(sweepBootVolumes) {
+		var err error
+		volumes, attachedTo, err = bootVolumeInventory(ctx, client, compartmentID, nil, retry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// OCI does not copy an instance's tags to its volumes, so a volume is
+	// traced back to the tool's instances instead: a boot volume by the name
+	// OCI gives it, and a block volume by the instance it was last attached to
+	var ownedNames, ownedIDs map[string]bool
+	if policy.sweeps(sweepBootVolumes) || policy.sweeps(sweepVolumes) {
+		var err error
+		ownedNames, ownedIDs, err = ownedInstances(ctx, client, compartmentID, policy, retry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var items []sweepItem
+	if policy.sweeps(sweepInstances) {
+		inPool := make(map[string]bool)
+		for _, p := range livePools {
+			members, err := client.ListInstancePoolInstances(ctx, compartmentID, *p.Id)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range members {
+				inPool[derefString(m.Id)] = true
+			}
+		}
+		bootGBs := make(map[string]int64)
+		for _, bv := range volumes {
+			if id := attachedTo[*bv.Id]; id != "" {
+				bootGBs[id] += bootVolumeSizeGBs(bv)
+			}
+		}
+		sel := instanceSelector{States: instanceStates}
+		instances, err := selectInstances(ctx, client, compartmentID, false, sel, retry)
+		if err != nil {
+			return nil, err
+		}
+		for _, inst := range instances {
+			id, name := *inst.Id, derefString(inst.DisplayName)
+			if !policy.owns(name, inst.FreeformTags) || inPool[id] || inManifest[id] || !oldEnough(timeCreated(inst)) {
+				continue
+			}
+			items = append(items, sweepItem{
+				Kind:    sweepInstances,
+				ID:      id,
+				Name:    name,
+				Reason:  string(inst.LifecycleState),
+				Created: timeCreated(inst),
+				SizeGBs: bootGBs[id],
+			})
+		}
+	}
+
+	if policy.sweeps(sweepInstanceConfigs) {
+		used := make(map[string]bool)
+		for _, p := range livePools {
+			used[derefString(p.InstanceConfigurationId)] = true
+		}
+		configs, err := client.ListInstanceConfigurations(ctx, compartmentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range configs {
+			id, name := *c.Id, derefString(c.DisplayName)
+			var created time.Time
+			if c.TimeCreated != nil {
+				created = c.TimeCreated.Time
+			}
+			if !policy.owns(name, c.FreeformTags) || used[id] || !oldEnough(created) {
+				continue
+			}
+			items = append(items, sweepItem{Kind: sweepInstanceConfigs, ID: id, Name: name, Reason: "no live pool", Created: created})
+		}
+	}
+
+	if policy.sweeps(sweepBootVolumes) {
+		for _, bv := range volumes {
+			id, name := *bv.Id, derefString(bv.DisplayName)
+			owned := policy.owns(name, bv.FreeformTags) || ownedNames[strings.TrimSuffix(name, bootVolumeNameSuffix)]
+			if attachedTo[id] != "" || !owned || !oldEnough(bootVolumeCreated(bv)) {
+				continue
+			}
+			items = append(items, sweepItem{
+				Kind:    sweepBootVolumes,
+				ID:      id,
+				Name:    name,
+				Reason:  "unattached",
+				Created: bootVolumeCreated(bv),
+				SizeGBs: bootVolumeSizeGBs(bv),
+			})
+		}
+	}
+
+	if policy.sweeps(sweepVolumes) {
+		volumes, detachedFrom, err := unattachedVolumes(ctx, client, compartmentID, retry)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range volumes {
+			id, name := *v.Id, derefString(v.DisplayName)
+			var created time.Time
+			if v.TimeCreated != nil {
+				created = v.TimeCreated.Time
+			}
+			if !(policy.owns(name, v.FreeformTags) || ownedIDs[detachedFrom[id]]) || !oldEnough(created) {
+				continue
+			}
+			var sizeGBs int64
+			switch {
+			case v.SizeInGBs != nil:
+				sizeGBs = *v.SizeInGBs
+			case v.SizeInMBs != nil:
+				sizeGBs = *v.SizeInMBs / 1024
+			}
+			items = append(items, sweepItem{Kind: sweepVolumes, ID: id, Name: name, Reason: "unattached", Created: created, SizeGBs: sizeGBs})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Created.Before(items[j].Created) })
+	return items, nil
+}
+
+// ownedInstances returns the display names and IDs of the instances in
+// compartmentID that the policy counts as the tool's, in any state. OCI lists
+// terminated instances for a while, long enough to trace the volumes they
+// leave behind.
+func ownedInstances(ctx context.Context, client *OCIClient, compartmentID string, policy SweepPolicy, retry RetryPolicy) (map[string]bool, map[string]bool, error) {
+	instances, err := listInstances(ctx, client.ComputeClient, core.ListInstancesRequest{CompartmentId: common.String(compartmentID)}, retry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing instances in %s: %w", compartmentID, err)
+	}
+	names := make(map[string]bool)
+	ids := make(map[string]bool)
+	for _, inst := range instances {
+		if name := derefString(inst.DisplayName); policy.owns(name, inst.FreeformTags) {
+			names[name] = true
+			ids[*inst.Id] = true
+		}
+	}
+	return names, ids, nil
+}
+
+// unattachedVolumes returns the AVAILABLE block volumes in compartmentID that
+// no instance has attached, and the instance each was detached from where the
+// service still lists the attachment. A volume attached to an instance is in
+// use even when the instance is stopped; it is swept once the instance is gone.
+func unattachedVolumes(ctx context.Context, client *OCIClient, compartmentID string, retry RetryPolicy) ([]core.Volume, map[string]string, error) {
+	var available []core.Volume
+	request := core.ListVolumesRequest{CompartmentId: common.String(compartmentID)}
+	for {
+		var resp core.ListVolumesResponse
+		_, err := withRetry(ctx, retry, "list block volumes", func(ctx context.Context) error {
+			var err error
+			resp, err = client.BlockstorageClient.ListVolumes(ctx, request)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing block volumes: %w", err)
+		}
+		for _, v := range resp.Items {
+			if v.LifecycleState == core.VolumeLifecycleStateAvailable {
+				available = append(available, v)
+			}
+		}
+		if resp.OpcNextPage == nil {
+			break
+		}
+		request.Page = resp.OpcNextPage
+	}
+	if len(available) == 0 {
+		return nil, nil, nil
+	}
+
+	attached := make(map[string]bool)
+	detachedFrom := make(map[string]string)
+	attachments := core.ListVolumeAttachmentsRequest{CompartmentId: common.String(compartmentID)}
+	for {
+		var resp core.ListVolumeAttachmentsResponse
+		_, err := withRetry(ctx, retry, "list volume attachments", func(ctx context.Context) error {
+			var err error
+			resp, err = client.ComputeClient.ListVolumeAttachments(ctx, attachments)
+			return err
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing volume attachments: %w", err)
+		}
+		for _, a := range resp.Items {
+			if a.GetLifecycleState() != core.VolumeAttachmentLifecycleStateDetached {
+				attached[derefString(a.GetVolumeId())] = true
+			} else {
+				detachedFrom[derefString(a.GetVolumeId())] = derefString(a.GetInstanceId())
+			}
+		}
+		if resp.OpcNextPage == nil {
+			break
+		}
+		attachments.Page = resp.OpcNextPage
+	}
+
+	var unattached []core.Volume
+	for _, v := range available {
+		if !attached[*v.Id] {
+			unattached = append(unattached, v)
+		}
+	}
+	return unattached, detachedFrom, nil
+}
+
+// printSweepPreview lists the leftovers with their age and estimated monthly
+// cost, followed by the totals. Only volume storage is costed: a stopped
+// instance of a standard shape is not billed for its OCPUs and memory, and an
+// instance configuration costs nothing.
+func printSweepPreview(items []sweepItem, pricePerGB float64, now time.Time) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  KIND\tNAME\tWHY\tAGE\tSTORAGE\tEST. $/MONTH\tID")
+	var totalGBs int64
+	for _, item := range items {
+		age := ""
+		if !item.Created.IsZero() {
+			age = formatAge(now.Sub(item.Created))
+		}
+		storage := "-"
+		if item.SizeGBs > 0 {
+			storage = fmt.Sprintf("%d GB", item.SizeGBs)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%.2f\t%s\n", item.Kind, item.Name, item.Reason, age, storage,
+			float64(item.SizeGBs)*pricePerGB, item.ID)
+		totalGBs += item.SizeGBs
+	}
+	w.Flush()
+
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Kind]++
+	}
+	printCounts("Resources per kind:", counts)
+	fmt.Printf("Storage held: %d GB, estimated $%.2f per month\n", totalGBs, float64(totalGBs)*pricePerGB)
+}
+
+// formatAge returns d in the largest whole unit of days, hours or minutes
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+// deleteLeftovers deletes items, instances first so their boot volumes go
+// with them, and returns how many could not be deleted
+func deleteLeftovers(ctx context.Context, client *OCIClient, items []sweepItem, parallel int, retry RetryPolicy) int {
+	var instanceIDs, configIDs, volumeIDs []string
+	var volumes []core.BootVolume
+	for _, item := range items {
+		switch item.Kind {
+		case sweepInstances:
+			instanceIDs = append(instanceIDs, item.ID)
+		case sweepInstanceConfigs:
+			configIDs = append(configIDs, item.ID)
+		case sweepBootVolumes:
+			volumes = append(volumes, core.BootVolume{Id: common.String(item.ID)})
+		case sweepVolumes:
+			volumeIDs = append(volumeIDs, item.ID)
+		}
+	}
+
+	failures := 0
+	if len(instanceIDs) > 0 {
+		for _, result := range terminateInstances(ctx, client.ComputeClient, instanceIDs, parallel, 0, false, retry) {
+			if !result.Accepted {
+				failures++
+			}
+		}
+	}
+	for _, id := range configIDs {
+		if err := client.DeleteInstanceConfiguration(ctx, id); err != nil {
+			fmt.Printf("❌ Failed to delete %s: %v\n", id, err)
+			failures++
+			continue
+		}
+		fmt.Printf("✓ Deleted instance configuration %s\n", id)
+	}
+	if len(volumes) > 0 {
+		failures += deleteBootVolumes(ctx, client.BlockstorageClient, volumes, parallel, retry)
+	}
+	for _, id := range volumeIDs {
+		_, err := withRetry(ctx, retry, "delete "+id, func(ctx context.Context) error {
+			_, err := client.BlockstorageClient.DeleteVolume(ctx, core.DeleteVolumeRequest{VolumeId: common.String(id)})
+			return err
+		})
+		if err != nil {
+			fmt.Printf("❌ Failed to delete %s: %v\n", id, err)
+			failures++
+			continue
+		}
+		fmt.Printf("✓ Deleted block volume %s\n", id)
+	}
+	return failures
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+func TestFindLeftoversTracesVolumesToTheToolsInstances(t *testing.T) {
+	b := ocifake.New()
+	ctx := context.Background()
+	ours := launchFake(t, b, "swbv-1", "run-a")
+	theirs := launchFake(t, b, "other-1", "")
+	for _, id := range []string{ours, theirs} {
+		if _, err := b.AddVolume(ocifake.BlockVolume{DisplayName: "data-" + id, InstanceID: id}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.TerminateInstance(ctx, core.TerminateInstanceRequest{InstanceId: common.String(id), PreserveBootVolume: common.Bool(true)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A volume nobody attached stays a stranger's too
+	if _, err := b.AddVolume(ocifake.BlockVolume{DisplayName: "loose", CompartmentID: "ocid1.compartment.test", AvailabilityDomain: "AD-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	policy := DefaultSweepPolicy()
+	policy.OlderThan = 0
+	policy.Kinds = []string{sweepBootVolumes, sweepVolumes}
+	items, err := findLeftovers(ctx, fakeClient(b), "ocid1.compartment.test", policy, nil, nil, testRetry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Kind+" "+item.Name)
+	}
+	want := []string{sweepBootVolumes + " swbv-1 (Boot Volume)", sweepVolumes + " data-" + ours}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("found %q, want %q", got, want)
+	}
+	for _, bv := range b.BootVolumes() {
+		if len(bv.FreeformTags) != 0 {
+			t.Errorf("boot volume %s carries tags %v; the test needs it untagged, as OCI leaves it", *bv.DisplayName, bv.FreeformTags)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// Freeform tags the tool stamps on every instance, instance configuration and
+// instance pool it creates, so later commands can find, audit and clean up
+// what a run made
+const (
+	runIDTag     = "oci-insta-scale-run-id"     // the run that created the resource
+	versionTag   = "oci-insta-scale-version"    // the tool version that created it
+	createdByTag = "oci-insta-scale-created-by" // the OCID of the user the tool ran as, when known
+	createdAtTag = "oci-insta-scale-created-at" // when the run started, RFC 3339 in UTC
+	expiresAtTag = "oci-insta-scale-expires-at" // when the resource may be reaped, RFC 3339 in UTC; absent means never
+)
+
+// keepTag protects a resource from reap whatever its expiry, e.g. when a
+// benchmark fleet is kept for a rerun
+const keepTag = "oci-insta-scale-keep"
+
+// version is the tool version recorded in versionTag, set at build time with
+// -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// runTags returns the standard tags for a resource created by run runID,
+// with the user's own tags beneath them. expiresAt may be nil.
+func runTags(runID, createdBy string, createdAt time.Time, expiresAt *time.Time, userTags map[string]string) map[string]string {
+	tags := make(map[string]string, len(userTags)+5)
+	for k, v := range userTags {
+		tags[k] = v
+	}
+	tags[runIDTag] = runID
+	tags[versionTag] = version
+	tags[createdAtTag] = createdAt.UTC().Format(time.RFC3339)
+	if createdBy != "" {
+		tags[createdByTag] = createdBy
+	}
+	if expiresAt != nil {
+		tags[expiresAtTag] = expiresAt.UTC().Format(time.RFC3339)
+	}
+	return tags
+}
+
+// expiryAfter returns when a resource created at createdAt with the given
+// time to live expires, or nil for a ttl of 0
+func expiryAfter(createdAt time.Time, ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	expiresAt := createdAt.Add(ttl).UTC()
+	return &expiresAt
+}
+
+// hasAnyTag reports whether tags has one of patterns, each a key, which
+// matches any value, or key=value
+func hasAnyTag(tags map[string]string, patterns []string) bool {
+	for _, pattern := range patterns {
+		key, value, hasValue := strings.Cut(pattern, "=")
+		if v, ok := tags[key]; ok && (!hasValue || v == value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestHasAnyTag(t *testing.T) {
+	tags := map[string]string{"team": "db", "keep": ""}
+
+	tests := []struct {
+		patterns []string
+		want     bool
+	}{
+		{nil, false},
+		{[]string{"team"}, true},
+		{[]string{"team=db"}, true},
+		{[]string{"team=web"}, false},
+		{[]string{"keep"}, true},
+		{[]string{"keep="}, true},
+		{[]string{"keep=yes"}, false},
+		{[]string{"owner", "team=web", "keep"}, true},
+		{[]string{"db"}, false},
+	}
+	for _, tt := range tests {
+		if got := hasAnyTag(tags, tt.patterns); got != tt.want {
+			t.Errorf("hasAnyTag(%q) = %v, want %v", tt.patterns, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+// runTerminate implements the terminate command
+func runTerminate(ctx context.Context, args []string) error {
+	fs := newFlagSet("terminate", "", "Terminate every instance in a launch run manifest or OCID list, or the instances in a\ncompartment that match the selector flags.")
+	var (
+		inputFile    = fs.String("file", "instances.json", "Run manifest from launch, or a file of instance OCIDs (one per line)")
+		compartment  = fs.String("compartment", "", "Compartment ID to select instances from (required with selector flags)")
+		parallel     = fs.Int("parallel", 10, "Number of parallel termination operations")
+		assumeYes    = fs.Bool("yes", false, "Terminate the selected instances without asking for confirmation")
+		dryRun       = fs.Bool("dry-run", false, "Show the instances the selector flags match without terminating them")
+		wait         = fs.Bool("wait", false, "Wait for each instance to reach TERMINATED and report how long it took")
+		waitTimeout  = fs.Duration("wait-timeout", 30*time.Minute, "How long -wait waits for each instance")
+		preserveBoot = fs.Bool("preserve-boot-volume", false, "Keep each instance's boot volume instead of deleting it (remove them later with cleanup boot-volumes)")
+	)
+	selectors := registerSelectorFlags(fs)
+	shared := registerCommonFlags(fs, "")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	config, err := shared.load()
+	if err != nil {
+		return err
+	}
+	set := flagsSet(fs)
+	fromConfig(set, "compartment", compartment, config.CompartmentID)
+
+	byQuery := selectors.given(set)
+	if byQuery && set["file"] {
+		return usageErrorf(fs, "-file cannot be combined with selector flags")
+	}
+	if byQuery && *compartment == "" {
+		return usageErrorf(fs, "compartment flag is required to select instances")
+	}
+	if *parallel < 1 {
+		return usageErrorf(fs, "parallel must be at least 1")
+	}
+	if !byQuery && *dryRun {
+		return usageErrorf(fs, "-dry-run needs selector flags")
+	}
+	if !byQuery && set["subtree"] {
+		return usageErrorf(fs, "-subtree needs selector flags to filter the instances by")
+	}
+	sel, err := selectors.selector(time.Now())
+	if err != nil {
+		return usageErrorf(fs, "%v", err)
+	}
+	if byQuery && sel.empty() {
+		return usageErrorf(fs, "selector flags must not all be empty: they would select every instance in the compartment")
+	}
+
+	// Create OCI client
+	client, err := NewOCIClient(config)
+	if err != nil {
+		return err
+	}
+	api := client.ComputeClient
+
+	var instanceIDs []string
+	if byQuery {
+		instances, err := selectInstances(ctx, client, *compartment, *selectors.subtree, sel, config.Retry)
+		if err != nil {
+			return err
+		}
+		if len(instances) == 0 {
+			fmt.Println("No instances match the selectors")
+			return nil
+		}
+		fmt.Printf("%d instance(s) match the selectors:\n", len(instances))
+		printInstancePreview(instances)
+		if *dryRun {
+			fmt.Println("\nDry run: nothing was terminated")
+			return nil
+		}
+		if !*assumeYes && !confirm(fmt.Sprintf("\nTerminate these %d instance(s)?", len(instances))) {
+			fmt.Println("Nothing was terminated")
+			return nil
+		}
+		for _, inst := range instances {
+			instanceIDs = append(instanceIDs, *inst.Id)
+		}
+	} else {
+		// Read instance IDs from file
+		instanceIDs, err = readInstancesFromFile(*inputFile)
+		if err != nil {
+			return fmt.Errorf("reading instances from file: %w", err)
+		}
+		if len(instanceIDs) == 0 {
+			fmt.Println("No instance IDs found in file")
+			return nil
+		}
+	}
+
+	fmt.Printf("Found %d instances to terminate\n", len(instanceIDs))
+
+	maxWait := time.Duration(0)
+	if *wait {
+		maxWait = *waitTimeout
+	}
+	results := terminateInstances(ctx, api, instanceIDs, *parallel, maxWait, *preserveBoot, config.Retry)
+
+	acceptedCount := 0
+	retriedCount := 0
+	var gone []time.Duration
+	for _, result := range results {
+		if result.Attempts > 1 {
+			retriedCount++
+		}
+		if result.Accepted {
+			acceptedCount++
+		}
+		if result.TerminatedAt != nil {
+			gone = append(gone, result.TerminatedAt.Sub(result.RequestedAt))
+		}
+	}
+
+	fmt.Printf("\nSummary: %d/%d terminations accepted", acceptedCount, len(instanceIDs))
+	if *wait {
+		fmt.Printf(", %d/%d instances TERMINATED", len(gone), len(instanceIDs))
+	}
+	fmt.Println()
+	if len(gone) > 0 {
+		sort.Slice(gone, func(i, j int) bool { return gone[i] < gone[j] })
+		var total time.Duration
+		for _, d := range gone {
+			total += d
+		}
+		fmt.Printf("Time to TERMINATED: p50 %s | mean %s | max %s\n", gone[len(gone)/2].Round(time.Second),
+			(total / time.Duration(len(gone))).Round(time.Second), gone[len(gone)-1].Round(time.Second))
+	}
+	if failureCount := len(instanceIDs) - acceptedCount; failureCount > 0 {
+		fmt.Printf("Failures: %d\n", failureCount)
+	}
+	if retriedCount > 0 {
+		fmt.Printf("Terminations that needed retries: %d\n", retriedCount)
+	}
+	if *preserveBoot && acceptedCount > 0 {
+		fmt.Println("Boot volumes were preserved; delete them with 'oci-insta-scale cleanup boot-volumes' once they are no longer needed")
+	}
+	if acceptedCount < len(instanceIDs) {
+		return fmt.Errorf("%d of %d instances failed to terminate", len(instanceIDs)-acceptedCount, len(instanceIDs))
+	}
+	if *wait && len(gone) < len(instanceIDs) {
+		return fmt.Errorf("%d of %d instances did not reach TERMINATED", len(instanceIDs)-len(gone), len(instanceIDs))
+	}
+	return nil
+}
+
+// terminateInstances terminates every instance in instanceIDs, at most
+// parallel at once (0 for no limit), reporting each result as it completes.
+// With a maxWait, each accepted instance is also polled until it is
+// TERMINATED. Boot volumes are deleted with their instances unless
+// preserveBootVolume is set.
+func terminateInstances(ctx context.Context, api ComputeAPI, instanceIDs []string, parallel int, maxWait time.Duration, preserveBootVolume bool, retry RetryPolicy) []TerminationResult {
+	results := make(chan TerminationResult, len(instanceIDs))
+	var wg sync.WaitGroup
+	var semaphore chan struct{}
+	if parallel > 0 {
+		semaphore = make(chan struct{}, parallel)
+	}
+
+	for _, instanceID := range instanceIDs {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if semaphore != nil {
+				semaphore <- struct{}{}        // Acquire
+				defer func() { <-semaphore }() // Release
+			}
+
+			result := terminateInstance(ctx, api, id, preserveBootVolume, retry)
+			if result.Accepted && maxWait > 0 {
+				result = awaitTerminated(ctx, api, result, maxWait, retry)
+			}
+			results <- result
+		}(instanceID)
+	}
+
+	// Wait for all goroutines to complete
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect and display results
+	var all []TerminationResult
+	for result := range results {
+		switch {
+		case !result.Accepted:
+			fmt.Printf("❌ Failed to terminate %s: %v\n", result.InstanceID, result.Error)
+		case result.Error != nil:
+			fmt.Printf("❌ Termination of %s accepted but not confirmed: %v\n", result.InstanceID, result.Error)
+		case result.TerminatedAt != nil:
+			fmt.Printf("✓ Terminated %s | attempts: %d | terminated in: %s\n",
+				result.InstanceID, result.Attempts, result.TerminatedAt.Sub(result.RequestedAt).Round(time.Second))
+		default:
+			fmt.Printf("✓ Termination accepted for %s | attempts: %d\n", result.InstanceID, result.Attempts)
+		}
+		all = append(all, result)
+	}
+	return all
+}
+
+type TerminationResult struct {
+	InstanceID   string
+	Error        error      // why the request failed or, when waiting, why TERMINATED was not seen
+	Attempts     int        // TerminateInstance calls made, including retries
+	RequestedAt  time.Time  // when the first TerminateInstance call was made
+	Accepted     bool       // TerminateInstance succeeded
+	TerminatedAt *time.Time // when the instance was first seen TERMINATED, if waited for
+}
+
+func terminateInstance(ctx context.Context, client ComputeAPI, instanceID string, preserveBootVolume bool, retry RetryPolicy) TerminationResult {
+	request := core.TerminateInstanceRequest{
+		InstanceId:         common.String(instanceID),
+		PreserveBootVolume: common.Bool(preserveBootVolume),
+	}
+
+	requestedAt := time.Now().UTC()
+	attempts, err := withRetry(ctx, retry, "terminate "+instanceID, func(ctx context.Context) error {
+		_, err := client.TerminateInstance(ctx, request)
+		return err
+	})
+	if err != nil {
+		return TerminationResult{
+			InstanceID:  instanceID,
+			Error:       fmt.Errorf("terminate failed: %w", err),
+			Attempts:    attempts,
+			RequestedAt: requestedAt,
+		}
+	}
+
+	return TerminationResult{
+		InstanceID:  instanceID,
+		Error:       nil,
+		Attempts:    attempts,
+		RequestedAt: requestedAt,
+		Accepted:    true,
+	}
+}
+
+// awaitTerminated waits for an instance whose termination was accepted to
+// reach TERMINATED and fills in when it got there, or why it was not seen to
+func awaitTerminated(ctx context.Context, client ComputeAPI, result TerminationResult, maxWait time.Duration, retry RetryPolicy) TerminationResult {
+	terminatedAt, err := waitForInstanceTerminated(ctx, client, result.InstanceID, 10*time.Second, maxWait, retry)
+	if err != nil {
+		result.Error = fmt.Errorf("terminate wait failed: %w", err)
+		return result
+	}
+	result.TerminatedAt = &terminatedAt
+	return result
+}
+
+// waitForInstanceTerminated polls until the instance is TERMINATED, or no
+// longer found, and returns when that was first seen
+func waitForInstanceTerminated(ctx context.Context, client ComputeAPI, instanceID string, interval time.Duration, maxWait time.Duration, retry RetryPolicy) (time.Time, error) {
+	ctxWait, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Immediate check before waiting
+	state, err := checkInstanceTerminated(ctxWait, client, instanceID, retry)
+	for err == nil && state != core.InstanceLifecycleStateTerminated {
+		select {
+		case <-ctxWait.Done():
+			return time.Time{}, fmt.Errorf("timeout waiting for terminated state, last seen %s: %w", state, ctxWait.Err())
+		case <-ticker.C:
+			state, err = checkInstanceTerminated(ctxWait, client, instanceID, retry)
+		}
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Now().UTC(), nil
+}
+
+// checkInstanceTerminated returns the instance's lifecycle state, taking an
+// instance the service no longer knows as TERMINATED
+func checkInstanceTerminated(ctx context.Context, client ComputeAPI, instanceID string, retry RetryPolicy) (core.InstanceLifecycleStateEnum, error) {
+	var resp core.GetInstanceResponse
+	_, err := withRetry(ctx, retry, "get "+instanceID, func(ctx context.Context) error {
+		var err error
+		resp, err = client.GetInstance(ctx, core.GetInstanceRequest{InstanceId: common.String(instanceID)})
+		return err
+	})
+	switch {
+	case isNotFound(err):
+		return core.InstanceLifecycleStateTerminated, nil
+	case err != nil:
+		return "", fmt.Errorf("get instance failed: %w", err)
+	}
+	return resp.Instance.LifecycleState, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/tomarkel/oci-insta-scale/ocifake"
+)
+
+// availableBootVolumes counts the boot volumes that have not been deleted
+func availableBootVolumes(b *ocifake.Backend) int {
+	n := 0
+	for _, bv := range b.BootVolumes() {
+		if bv.LifecycleState == core.BootVolumeLifecycleStateAvailable {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTerminateInstances(t *testing.T) {
+	for _, parallel := range []int{0, 1, 4} {
+		b := ocifake.New()
+		ids := []string{launchFake(t, b, "web-1", ""), launchFake(t, b, "web-2", ""), launchFake(t, b, "web-3", "")}
+		b.InjectError(ocifake.OpTerminateInstance, ocifake.TooManyRequests(), 1)
+
+		results := terminateInstances(context.Background(), b, append(ids, "ocid1.instance.missing"), parallel, time.Minute, false, testRetry)
+		if len(results) != 4 {
+			t.Fatalf("parallel %d: %d results, want 4", parallel, len(results))
+		}
+		attempts := 0
+		for _, r := range results {
+			attempts += r.Attempts
+			if r.InstanceID == "ocid1.instance.missing" {
+				if r.Accepted || r.Error == nil {
+					t.Errorf("parallel %d: missing instance accepted=%v err=%v, want a failure", parallel, r.Accepted, r.Error)
+				}
+				continue
+			}
+			if !r.Accepted || r.Error != nil || r.TerminatedAt == nil {
+				t.Errorf("parallel %d: %s accepted=%v err=%v terminated=%v, want TERMINATED", parallel, r.InstanceID, r.Accepted, r.Error, r.TerminatedAt)
+			}
+		}
+		// One call per instance and one retry after the 429
+		if attempts != 5 {
+			t.Errorf("parallel %d: %d TerminateInstance attempts, want 5", parallel, attempts)
+		}
+		if n := availableBootVolumes(b); n != 0 {
+			t.Errorf("parallel %d: %d boot volumes left, want them deleted with their instances", parallel, n)
+		}
+	}
+}
+
+func TestTerminateInstancesPreservesBootVolumes(t *testing.T) {
+	b := ocifake.New()
+	id := launchFake(t, b, "web-1", "")
+
+	results := terminateInstances(context.Background(), b, []string{id}, 1, 0, true, testRetry)
+	if len(results) != 1 || !results[0].Accepted || results[0].TerminatedAt != nil {
+		t.Fatalf("results %+v, want one accepted termination that was not waited for", results)
+	}
+	if n := availableBootVolumes(b); n != 1 {
+		t.Errorf("%d boot volumes left, want the preserved one", n)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// planWaves splits configs into consecutive waves of at most size instances.
+// A size of 0 or less puts everything in one wave.
+func planWaves(configs []InstanceConfig, size int) [][]InstanceConfig {
+	if size <= 0 || size >= len(configs) {
+		return [][]InstanceConfig{configs}
+	}
+	var waves [][]InstanceConfig
+	for start := 0; start < len(configs); start += size {
+		end := start + size
+		if end > len(configs) {
+			end = len(configs)
+		}
+		waves = append(waves, configs[start:end])
+	}
+	return waves
+}
+
+// launchInstances creates every instance in configs, with at most parallel
+// creations in flight (0 for no limit), and sends each result to results as
+// it completes. It returns once every instance is running or has failed.
+func launchInstances(ctx context.Context, client ComputeAPI, configs []InstanceConfig, parallel int, retry RetryPolicy, journal *runJournal, results chan<- InstanceResult) {
+	var wg sync.WaitGroup
+	var semaphore chan struct{}
+	if parallel > 0 {
+		semaphore = make(chan struct{}, parallel)
+	}
+
+	for _, config := range configs {
+		wg.Add(1)
+		go func(config InstanceConfig) {
+			defer wg.Done()
+			if semaphore != nil {
+				semaphore <- struct{}{}        // Acquire
+				defer func() { <-semaphore }() // Release
+			}
+			if ctx.Err() != nil {
+				return // interrupted before this instance was started
+			}
+
+			results <- createInstance(ctx, client, config, retry, journal)
+		}(config)
+	}
+
+	wg.Wait()
+}
+
+// awaitLaunched waits for instances that were already launched, or adopted,
+// to reach RUNNING, with at most parallel polling at once (0 for no limit),
+// journaling and reporting each result as it completes
+func awaitLaunched(ctx context.Context, client ComputeAPI, launched []InstanceResult, parallel int, retry RetryPolicy, journal *runJournal, tally *launchTally) {
+	if len(launched) == 0 {
+		return
+	}
+	results := make(chan InstanceResult, len(launched))
+	go func() {
+		var wg sync.WaitGroup
+		var semaphore chan struct{}
+		if parallel > 0 {
+			semaphore = make(chan struct{}, parallel)
+		}
+		for _, result := range launched {
+			wg.Add(1)
+			go func(result InstanceResult) {
+				defer wg.Done()
+				if semaphore != nil {
+					semaphore <- struct{}{}        // Acquire
+					defer func() { <-semaphore }() // Release
+				}
+
+				results <- awaitRunning(ctx, client, result, retry)
+			}(result)
+		}
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		journal.record(result)
+		tally.add(result)
+	}
+}
+
+// printWaveSummary reports how many instances in a wave reached RUNNING and
+// how long they took to get there
+func printWaveSummary(wave, total int, results []InstanceResult, elapsed time.Duration) {
+	var ready []time.Duration
+	for _, r := range results {
+		if r.Error == nil && r.RunningAt != nil {
+			ready = append(ready, r.RunningAt.Sub(r.LaunchStarted))
+		}
+	}
+
+	fmt.Printf("--- Wave %d/%d: %d/%d running, %d failed | wave took: %s",
+		wave, total, len(ready), len(results), len(results)-len(ready), elapsed.Round(time.Second))
+	if len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return ready[i] < ready[j] })
+		fmt.Printf(" | ready p50: %s | ready max: %s",
+			ready[len(ready)/2].Round(time.Second), ready[len(ready)-1].Round(time.Second))
+	}
+	fmt.Println()
+}